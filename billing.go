@@ -1,14 +1,26 @@
 package tedo
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"maps"
+	"math"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 )
 
 // BillingService handles billing-related API calls.
 type BillingService struct {
 	client *Client
+
+	entitlementKeys entitlementKeyCache
 }
 
 // Plan/price keys for Tedo's built-in billing plans.
@@ -34,8 +46,8 @@ type Plan struct {
 	IsActive     bool          `json:"is_active"`
 	Prices       []Price       `json:"prices,omitempty"`
 	Entitlements []Entitlement `json:"entitlements,omitempty"`
-	CreatedAt    time.Time     `json:"created_at"`
-	UpdatedAt    time.Time     `json:"updated_at,omitempty"`
+	CreatedAt    Time          `json:"created_at"`
+	UpdatedAt    Time          `json:"updated_at,omitempty"`
 }
 
 // CreatePlanParams are the parameters for creating a plan.
@@ -46,35 +58,86 @@ type CreatePlanParams struct {
 }
 
 // CreatePlan creates a new subscription plan.
-func (s *BillingService) CreatePlan(ctx context.Context, params *CreatePlanParams) (*Plan, error) {
+func (s *BillingService) CreatePlan(ctx context.Context, params *CreatePlanParams, opts ...RequestOption) (*Plan, error) {
 	var plan Plan
-	err := s.client.request(ctx, "POST", "/billing/v1/plans", params, &plan)
+	ctx, path := s.op(ctx, "CreatePlan", "/billing/v1/plans")
+	err := s.client.request(ctx, "POST", path, params, &plan, opts...)
 	if err != nil {
 		return nil, err
 	}
 	return &plan, nil
 }
 
+// ListPlansParams are the parameters for listing plans. All fields are
+// optional.
+type ListPlansParams struct {
+	Limit    int
+	Cursor   string
+	IsActive *bool  // only active (true) or inactive (false) plans
+	Key      string // only the plan with this key
+}
+
 // PlanList is a list of plans.
 type PlanList struct {
-	Plans []Plan `json:"plans"`
-	Total int    `json:"total"`
+	Plans      []Plan `json:"plans"`
+	Total      int    `json:"total"`
+	NextCursor string `json:"next_cursor,omitempty"`
 }
 
-// ListPlans lists all plans.
-func (s *BillingService) ListPlans(ctx context.Context) (*PlanList, error) {
+// ListPlans lists plans, one page at a time. A nil params lists the first
+// page of all plans.
+func (s *BillingService) ListPlans(ctx context.Context, params *ListPlansParams, opts ...RequestOption) (*PlanList, error) {
+	query := queryParams{}
+	if params != nil {
+		query.setInt("limit", params.Limit)
+		query.set("cursor", params.Cursor)
+		query.setBool("is_active", params.IsActive)
+		query.set("key", params.Key)
+	}
+	ctx, path := s.op(ctx, "ListPlans", "/billing/v1/plans")
+
 	var list PlanList
-	err := s.client.request(ctx, "GET", "/billing/v1/plans", nil, &list)
+	err := s.client.request(ctx, "GET", query.path(path), nil, &list, opts...)
 	if err != nil {
 		return nil, err
 	}
 	return &list, nil
 }
 
+// listAllPlans fetches every page of plans matching params.
+func (s *BillingService) listAllPlans(ctx context.Context, params *ListPlansParams, opts ...RequestOption) ([]Plan, error) {
+	var p ListPlansParams
+	if params != nil {
+		p = *params
+	}
+
+	var plans []Plan
+	cursors := newCursorGuard(p.Cursor)
+	for {
+		list, err := s.ListPlans(ctx, &p, opts...)
+		if err != nil {
+			return nil, err
+		}
+		plans = append(plans, list.Plans...)
+
+		if err := cursors.next(list.NextCursor); err != nil {
+			return nil, err
+		}
+		if list.NextCursor == "" {
+			return plans, nil
+		}
+		p.Cursor = list.NextCursor
+	}
+}
+
 // GetPlan retrieves a plan by ID.
-func (s *BillingService) GetPlan(ctx context.Context, id string) (*Plan, error) {
+func (s *BillingService) GetPlan(ctx context.Context, id string, opts ...RequestOption) (*Plan, error) {
 	var plan Plan
-	err := s.client.request(ctx, "GET", "/billing/v1/plans/"+id, nil, &plan)
+	ctx, path, err := s.route(ctx, "GetPlan", "/billing/v1/plans/{planID}", id)
+	if err != nil {
+		return nil, err
+	}
+	err = s.client.request(ctx, "GET", path, nil, &plan, opts...)
 	if err != nil {
 		return nil, err
 	}
@@ -90,9 +153,13 @@ type UpdatePlanParams struct {
 }
 
 // UpdatePlan updates a plan.
-func (s *BillingService) UpdatePlan(ctx context.Context, id string, params *UpdatePlanParams) (*Plan, error) {
+func (s *BillingService) UpdatePlan(ctx context.Context, id string, params *UpdatePlanParams, opts ...RequestOption) (*Plan, error) {
 	var plan Plan
-	err := s.client.request(ctx, "PATCH", "/billing/v1/plans/"+id, params, &plan)
+	ctx, path, err := s.route(ctx, "UpdatePlan", "/billing/v1/plans/{planID}", id)
+	if err != nil {
+		return nil, err
+	}
+	err = s.client.request(ctx, "PATCH", path, params, &plan, opts...)
 	if err != nil {
 		return nil, err
 	}
@@ -100,8 +167,12 @@ func (s *BillingService) UpdatePlan(ctx context.Context, id string, params *Upda
 }
 
 // DeletePlan deletes (deactivates) a plan.
-func (s *BillingService) DeletePlan(ctx context.Context, id string) error {
-	return s.client.request(ctx, "DELETE", "/billing/v1/plans/"+id, nil, nil)
+func (s *BillingService) DeletePlan(ctx context.Context, id string, opts ...RequestOption) error {
+	ctx, path, err := s.route(ctx, "DeletePlan", "/billing/v1/plans/{planID}", id)
+	if err != nil {
+		return err
+	}
+	return s.client.request(ctx, "DELETE", path, nil, nil, opts...)
 }
 
 // ============================================================
@@ -110,31 +181,156 @@ func (s *BillingService) DeletePlan(ctx context.Context, id string) error {
 
 // Price represents a price for a plan.
 type Price struct {
-	ID            string    `json:"id"`
-	PlanID        string    `json:"plan_id"`
-	Key           string    `json:"key"`
-	Amount        int       `json:"amount"` // in cents
-	Currency      string    `json:"currency"`
-	Interval      string    `json:"interval"` // month, year
-	IntervalCount int       `json:"interval_count"`
-	TrialDays     int       `json:"trial_days,omitempty"`
-	CreatedAt     time.Time `json:"created_at"`
+	ID            string      `json:"id"`
+	PlanID        string      `json:"plan_id"`
+	PlanKey       string      `json:"plan_key,omitempty"` // set by ListAllPrices only
+	Key           string      `json:"key"`
+	Amount        int         `json:"amount"` // in cents
+	Currency      string      `json:"currency"`
+	Interval      string      `json:"interval"` // month, year
+	IntervalCount int         `json:"interval_count"`
+	TrialDays     int         `json:"trial_days,omitempty"`
+	BillingScheme string      `json:"billing_scheme,omitempty"` // per_unit (default), tiered
+	TiersMode     string      `json:"tiers_mode,omitempty"`     // graduated, volume
+	Tiers         []PriceTier `json:"tiers,omitempty"`
+	CreatedAt     Time        `json:"created_at"`
+}
+
+// Billing schemes and tier modes for prices.
+const (
+	BillingSchemePerUnit = "per_unit"
+	BillingSchemeTiered  = "tiered"
+
+	// TiersModeGraduated prices each unit at the tier it falls into.
+	TiersModeGraduated = "graduated"
+	// TiersModeVolume prices every unit at the tier the total quantity reaches.
+	TiersModeVolume = "volume"
+)
+
+// PriceTier is one tier of a tiered price. Tiers are ordered by UpTo; the last
+// tier has a nil UpTo and covers all remaining units.
+type PriceTier struct {
+	UpTo       *int `json:"up_to"`       // inclusive upper bound, nil for infinity
+	UnitAmount int  `json:"unit_amount"` // in cents, per unit
+	FlatAmount int  `json:"flat_amount"` // in cents, once per tier reached
+}
+
+// CostFor returns the cost of quantity units at this price.
+//
+// Per-unit prices charge Amount per unit. Graduated tiers charge each unit at
+// the tier it falls into, plus the flat amount of every tier that is reached.
+// Volume tiers charge all units at the single tier containing quantity, plus
+// that tier's flat amount. A tier's UpTo is inclusive, so a quantity equal to
+// it falls into that tier.
+//
+// A quantity above the UpTo of the last tier, when that tier is bounded,
+// can't be priced, nor can a tiered price without tiers; both yield an
+// error matching ErrValidation.
+func (p *Price) CostFor(quantity int) (Money, error) {
+	cost := Money{Currency: p.Currency}
+	if quantity <= 0 {
+		return cost, nil
+	}
+	if p.BillingScheme != BillingSchemeTiered {
+		cost.Amount = p.Amount * quantity
+		return cost, nil
+	}
+	if n := len(p.Tiers); n == 0 || p.Tiers[n-1].UpTo != nil && quantity > *p.Tiers[n-1].UpTo {
+		return Money{}, fmt.Errorf("%w: quantity %d exceeds the tiers of price %s", ErrValidation, quantity, p.Key)
+	}
+
+	if p.TiersMode == TiersModeVolume {
+		for _, tier := range p.Tiers {
+			if tier.UpTo == nil || quantity <= *tier.UpTo {
+				cost.Amount = quantity*tier.UnitAmount + tier.FlatAmount
+				break
+			}
+		}
+		return cost, nil
+	}
+
+	lower := 0
+	for _, tier := range p.Tiers {
+		upper := quantity
+		if tier.UpTo != nil && *tier.UpTo < quantity {
+			upper = *tier.UpTo
+		}
+		if units := upper - lower; units > 0 {
+			cost.Amount += units*tier.UnitAmount + tier.FlatAmount
+		}
+		if upper >= quantity {
+			break
+		}
+		lower = upper
+	}
+	return cost, nil
 }
 
 // CreatePriceParams are the parameters for creating a price.
 type CreatePriceParams struct {
-	Key           string `json:"key"`
-	Amount        int    `json:"amount"`
-	Currency      string `json:"currency,omitempty"`
-	Interval      string `json:"interval,omitempty"`
-	IntervalCount int    `json:"interval_count,omitempty"`
-	TrialDays     int    `json:"trial_days,omitempty"`
+	Key           string      `json:"key"`
+	Amount        int         `json:"amount"`
+	Currency      string      `json:"currency,omitempty"`
+	Interval      string      `json:"interval,omitempty"`
+	IntervalCount int         `json:"interval_count,omitempty"`
+	TrialDays     int         `json:"trial_days,omitempty"`
+	BillingScheme string      `json:"billing_scheme,omitempty"`
+	TiersMode     string      `json:"tiers_mode,omitempty"`
+	Tiers         []PriceTier `json:"tiers,omitempty"`
+}
+
+// defaultMaxIntervalCount is the largest IntervalCount accepted per Interval
+// unless changed with WithMaxIntervalCount. Intervals not listed only need
+// a positive count.
+var defaultMaxIntervalCount = map[string]int{
+	"month": 12,
+	"year":  3,
+}
+
+// Validate checks that IntervalCount is sensible for Interval, under the
+// default limits of 12 months and 3 years. A zero IntervalCount is left to
+// the API default of 1, and an empty Interval is checked as "month". Errors
+// match ErrValidation.
+func (p *CreatePriceParams) Validate() error {
+	return p.validate(defaultMaxIntervalCount)
+}
+
+// validate is Validate with the given IntervalCount limits.
+func (p *CreatePriceParams) validate(maxIntervalCount map[string]int) error {
+	if p == nil {
+		return fmt.Errorf("%w: params are required", ErrValidation)
+	}
+	if p.IntervalCount == 0 {
+		return nil
+	}
+	if p.IntervalCount < 0 {
+		return fmt.Errorf("%w: interval_count must be positive, got %d", ErrValidation, p.IntervalCount)
+	}
+
+	interval := p.Interval
+	if interval == "" {
+		interval = "month"
+	}
+	if limit, ok := maxIntervalCount[interval]; ok && p.IntervalCount > limit {
+		return fmt.Errorf("%w: interval_count for %s must be between 1 and %d, got %d", ErrValidation, interval, limit, p.IntervalCount)
+	}
+	return nil
 }
 
-// CreatePrice creates a new price for a plan.
-func (s *BillingService) CreatePrice(ctx context.Context, planID string, params *CreatePriceParams) (*Price, error) {
+// CreatePrice creates a new price for a plan. params are checked as by
+// Validate, with any limits set with WithMaxIntervalCount, before the
+// request is sent.
+func (s *BillingService) CreatePrice(ctx context.Context, planID string, params *CreatePriceParams, opts ...RequestOption) (*Price, error) {
+	if err := params.validate(s.client.maxIntervalCount); err != nil {
+		return nil, err
+	}
+
 	var price Price
-	err := s.client.request(ctx, "POST", "/billing/v1/plans/"+planID+"/prices", params, &price)
+	ctx, path, err := s.route(ctx, "CreatePrice", "/billing/v1/plans/{planID}/prices", planID)
+	if err != nil {
+		return nil, err
+	}
+	err = s.client.request(ctx, "POST", path, params, &price, opts...)
 	if err != nil {
 		return nil, err
 	}
@@ -143,23 +339,183 @@ func (s *BillingService) CreatePrice(ctx context.Context, planID string, params
 
 // PriceList is a list of prices.
 type PriceList struct {
-	Prices []Price `json:"prices"`
-	Total  int     `json:"total"`
+	Prices     []Price `json:"prices"`
+	Total      int     `json:"total"`
+	NextCursor string  `json:"next_cursor,omitempty"` // ListAllPrices only; empty on the last page
 }
 
 // ListPrices lists all prices for a plan.
-func (s *BillingService) ListPrices(ctx context.Context, planID string) (*PriceList, error) {
+func (s *BillingService) ListPrices(ctx context.Context, planID string, opts ...RequestOption) (*PriceList, error) {
+	var list PriceList
+	ctx, path, err := s.route(ctx, "ListPrices", "/billing/v1/plans/{planID}/prices", planID)
+	if err != nil {
+		return nil, err
+	}
+	err = s.client.request(ctx, "GET", path, nil, &list, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &list, nil
+}
+
+// ListAllPricesParams are the parameters for ListAllPrices. Zero fields
+// don't filter.
+type ListAllPricesParams struct {
+	Currency string
+	Interval string // month, year
+	IsActive *bool  // only prices of active (true) or inactive (false) plans
+	Limit    int
+	Cursor   string
+}
+
+// ListAllPrices lists one page of the prices of all plans, with PlanKey set
+// to the owning plan's key. Pass NextCursor as Cursor to get the next page.
+// When the API omits plan keys they are looked up with ListPlans.
+func (s *BillingService) ListAllPrices(ctx context.Context, params *ListAllPricesParams, opts ...RequestOption) (*PriceList, error) {
+	query := queryParams{}
+	if params != nil {
+		query.set("currency", params.Currency)
+		query.set("interval", params.Interval)
+		query.setBool("is_active", params.IsActive)
+		query.setInt("limit", params.Limit)
+		query.set("cursor", params.Cursor)
+	}
+	ctx, path := s.op(ctx, "ListAllPrices", "/billing/v1/prices")
+
 	var list PriceList
-	err := s.client.request(ctx, "GET", "/billing/v1/plans/"+planID+"/prices", nil, &list)
+	err := s.client.request(ctx, "GET", query.path(path), nil, &list, opts...)
 	if err != nil {
 		return nil, err
 	}
+	if err := s.fillPlanKeys(ctx, list.Prices, opts...); err != nil {
+		return nil, err
+	}
 	return &list, nil
 }
 
+// fillPlanKeys sets the PlanKey of prices that lack one, fetching the plans
+// only if needed.
+func (s *BillingService) fillPlanKeys(ctx context.Context, prices []Price, opts ...RequestOption) error {
+	if !slices.ContainsFunc(prices, func(p Price) bool { return p.PlanKey == "" }) {
+		return nil
+	}
+	plans, err := s.listAllPlans(ctx, nil, opts...)
+	if err != nil {
+		return fmt.Errorf("look up plan keys: %w", err)
+	}
+	keys := make(map[string]string, len(plans))
+	for _, plan := range plans {
+		keys[plan.ID] = plan.Key
+	}
+	for i := range prices {
+		if prices[i].PlanKey == "" {
+			prices[i].PlanKey = keys[prices[i].PlanID]
+		}
+	}
+	return nil
+}
+
+// GetPrice retrieves a price of a plan.
+func (s *BillingService) GetPrice(ctx context.Context, planID, priceID string, opts ...RequestOption) (*Price, error) {
+	var price Price
+	ctx, path, err := s.route(ctx, "GetPrice", "/billing/v1/plans/{planID}/prices/{priceID}", planID, priceID)
+	if err != nil {
+		return nil, err
+	}
+	err = s.client.request(ctx, "GET", path, nil, &price, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &price, nil
+}
+
+// GetPriceByID retrieves a price by ID alone, for when the plan isn't known.
+func (s *BillingService) GetPriceByID(ctx context.Context, priceID string, opts ...RequestOption) (*Price, error) {
+	var price Price
+	ctx, path, err := s.route(ctx, "GetPriceByID", "/billing/v1/prices/{priceID}", priceID)
+	if err != nil {
+		return nil, err
+	}
+	err = s.client.request(ctx, "GET", path, nil, &price, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &price, nil
+}
+
+// GetPriceByKey resolves a plan key and price key, as accepted by
+// CreateSubscriptionParams, to the price. It returns an error matching
+// ErrNotFound if either key doesn't match.
+func (s *BillingService) GetPriceByKey(ctx context.Context, planKey, priceKey string, opts ...RequestOption) (*Price, error) {
+	if planKey == "" {
+		return nil, fmt.Errorf("%w: planKey is required", ErrValidation)
+	}
+	if priceKey == "" {
+		return nil, fmt.Errorf("%w: priceKey is required", ErrValidation)
+	}
+
+	plans, err := s.ListPlans(ctx, &ListPlansParams{Key: planKey}, opts...)
+	if err != nil {
+		return nil, err
+	}
+	var plan *Plan
+	for i := range plans.Plans {
+		if plans.Plans[i].Key == planKey {
+			plan = &plans.Plans[i]
+			break
+		}
+	}
+	if plan == nil {
+		return nil, fmt.Errorf("%w: no plan with key %q", ErrNotFound, planKey)
+	}
+
+	prices, err := s.ListPrices(ctx, plan.ID, opts...)
+	if err != nil {
+		return nil, err
+	}
+	for i := range prices.Prices {
+		if prices.Prices[i].Key == priceKey {
+			return &prices.Prices[i], nil
+		}
+	}
+	return nil, fmt.Errorf("%w: plan %q has no price with key %q", ErrNotFound, planKey, priceKey)
+}
+
+// BuiltinPrices are the prices of Tedo's built-in plans.
+type BuiltinPrices struct {
+	Guest *Price // GuestPriceKey
+	Free  *Price // FreePriceKey
+	Basic *Price // BasicPriceKey
+}
+
+// GetBuiltinPrices retrieves the prices of the built-in guest, free and
+// basic plans, e.g. to show them on a pricing page.
+func (s *BillingService) GetBuiltinPrices(ctx context.Context, opts ...RequestOption) (*BuiltinPrices, error) {
+	var prices BuiltinPrices
+	for _, p := range []struct {
+		price             **Price
+		planKey, priceKey string
+	}{
+		{&prices.Guest, GuestPlanKey, GuestPriceKey},
+		{&prices.Free, FreePlanKey, FreePriceKey},
+		{&prices.Basic, BasicPlanKey, BasicPriceKey},
+	} {
+		price, err := s.GetPriceByKey(ctx, p.planKey, p.priceKey, opts...)
+		if err != nil {
+			return nil, err
+		}
+		*p.price = price
+	}
+	return &prices, nil
+}
+
 // ArchivePrice archives a price.
-func (s *BillingService) ArchivePrice(ctx context.Context, planID, priceID string) error {
-	return s.client.request(ctx, "DELETE", "/billing/v1/plans/"+planID+"/prices/"+priceID, nil, nil)
+func (s *BillingService) ArchivePrice(ctx context.Context, planID, priceID string, opts ...RequestOption) error {
+	ctx, path, err := s.route(ctx, "ArchivePrice", "/billing/v1/plans/{planID}/prices/{priceID}", planID, priceID)
+	if err != nil {
+		return err
+	}
+	return s.client.request(ctx, "DELETE", path, nil, nil, opts...)
 }
 
 // ============================================================
@@ -170,14 +526,36 @@ func (s *BillingService) ArchivePrice(ctx context.Context, planID, priceID strin
 type Entitlement struct {
 	ID           string    `json:"id"`
 	PlanID       string    `json:"plan_id"`
+	PlanKey      string    `json:"plan_key,omitempty"` // set by ListAllEntitlements
 	Key          string    `json:"key"`
 	ValueBool    *bool     `json:"value_bool,omitempty"`
 	ValueInt     *int      `json:"value_int,omitempty"`
-	OveragePrice int       `json:"overage_price,omitempty"`
+	OveragePrice int       `json:"overage_price,omitempty"` // in cents, per OverageUnit units
 	OverageUnit  int       `json:"overage_unit,omitempty"`
 	CreatedAt    time.Time `json:"created_at"`
 }
 
+// OverageCost returns the cost of using units beyond the entitlement's
+// included limit (ValueInt); units is the overage, not the total usage.
+// OveragePrice is charged per started block of OverageUnit units, so partial
+// blocks round up (e.g. 1001 units at 500 per block bill three blocks). An
+// OverageUnit of zero is treated as one, and zero or negative units, as
+// when usage stays within the limit, cost nothing.
+//
+// Entitlements carry no currency; the amount is in the currency of the plan's
+// price, so the returned Money has an empty Currency.
+func (e *Entitlement) OverageCost(units int) Money {
+	if units <= 0 || e.OveragePrice <= 0 {
+		return Money{}
+	}
+	unit := e.OverageUnit
+	if unit <= 0 {
+		unit = 1
+	}
+	blocks := (units + unit - 1) / unit
+	return Money{Amount: blocks * e.OveragePrice}
+}
+
 // CreateEntitlementParams are the parameters for creating an entitlement.
 type CreateEntitlementParams struct {
 	Key          string `json:"key"`
@@ -188,9 +566,13 @@ type CreateEntitlementParams struct {
 }
 
 // CreateEntitlement creates an entitlement for a plan.
-func (s *BillingService) CreateEntitlement(ctx context.Context, planID string, params *CreateEntitlementParams) (*Entitlement, error) {
+func (s *BillingService) CreateEntitlement(ctx context.Context, planID string, params *CreateEntitlementParams, opts ...RequestOption) (*Entitlement, error) {
 	var entitlement Entitlement
-	err := s.client.request(ctx, "POST", "/billing/v1/plans/"+planID+"/entitlements", params, &entitlement)
+	ctx, path, err := s.route(ctx, "CreateEntitlement", "/billing/v1/plans/{planID}/entitlements", planID)
+	if err != nil {
+		return nil, err
+	}
+	err = s.client.request(ctx, "POST", path, params, &entitlement, opts...)
 	if err != nil {
 		return nil, err
 	}
@@ -204,9 +586,13 @@ type EntitlementList struct {
 }
 
 // ListEntitlements lists all entitlements for a plan.
-func (s *BillingService) ListEntitlements(ctx context.Context, planID string) (*EntitlementList, error) {
+func (s *BillingService) ListEntitlements(ctx context.Context, planID string, opts ...RequestOption) (*EntitlementList, error) {
 	var list EntitlementList
-	err := s.client.request(ctx, "GET", "/billing/v1/plans/"+planID+"/entitlements", nil, &list)
+	ctx, path, err := s.route(ctx, "ListEntitlements", "/billing/v1/plans/{planID}/entitlements", planID)
+	if err != nil {
+		return nil, err
+	}
+	err = s.client.request(ctx, "GET", path, nil, &list, opts...)
 	if err != nil {
 		return nil, err
 	}
@@ -214,8 +600,75 @@ func (s *BillingService) ListEntitlements(ctx context.Context, planID string) (*
 }
 
 // ArchiveEntitlement archives an entitlement.
-func (s *BillingService) ArchiveEntitlement(ctx context.Context, planID, entitlementID string) error {
-	return s.client.request(ctx, "DELETE", "/billing/v1/plans/"+planID+"/entitlements/"+entitlementID, nil, nil)
+func (s *BillingService) ArchiveEntitlement(ctx context.Context, planID, entitlementID string, opts ...RequestOption) error {
+	ctx, path, err := s.route(ctx, "ArchiveEntitlement", "/billing/v1/plans/{planID}/entitlements/{entitlementID}", planID, entitlementID)
+	if err != nil {
+		return err
+	}
+	return s.client.request(ctx, "DELETE", path, nil, nil, opts...)
+}
+
+// entitlementKeysTTL is how long ListEntitlementKeys caches its result.
+const entitlementKeysTTL = 5 * time.Minute
+
+// entitlementKeyCache holds the entitlement keys defined across active plans.
+type entitlementKeyCache struct {
+	mu        sync.Mutex
+	keys      []string
+	fetchedAt time.Time
+}
+
+// ListEntitlementKeys returns the sorted, de-duplicated entitlement keys
+// defined across all active plans. The result is cached for five minutes, so
+// it is cheap to call on hot paths such as validating keys before
+// CheckEntitlement.
+func (s *BillingService) ListEntitlementKeys(ctx context.Context, opts ...RequestOption) ([]string, error) {
+	s.entitlementKeys.mu.Lock()
+	defer s.entitlementKeys.mu.Unlock()
+
+	if s.entitlementKeys.keys != nil && time.Since(s.entitlementKeys.fetchedAt) < entitlementKeysTTL {
+		return append([]string(nil), s.entitlementKeys.keys...), nil
+	}
+
+	active := true
+	plans, err := s.listAllPlans(ctx, &ListPlansParams{IsActive: &active}, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]bool)
+	keys := []string{}
+	for _, plan := range plans {
+		if !plan.IsActive {
+			continue
+		}
+		list, err := s.ListEntitlements(ctx, plan.ID, opts...)
+		if err != nil {
+			return nil, err
+		}
+		for _, entitlement := range list.Entitlements {
+			if !seen[entitlement.Key] {
+				seen[entitlement.Key] = true
+				keys = append(keys, entitlement.Key)
+			}
+		}
+	}
+	sort.Strings(keys)
+
+	s.entitlementKeys.keys = keys
+	s.entitlementKeys.fetchedAt = time.Now()
+	return append([]string(nil), keys...), nil
+}
+
+// IsKnownEntitlementKey reports whether key is defined on any active plan.
+// It uses the same cache as ListEntitlementKeys.
+func (s *BillingService) IsKnownEntitlementKey(ctx context.Context, key string, opts ...RequestOption) (bool, error) {
+	keys, err := s.ListEntitlementKeys(ctx, opts...)
+	if err != nil {
+		return false, err
+	}
+	i := sort.SearchStrings(keys, key)
+	return i < len(keys) && keys[i] == key, nil
 }
 
 // ============================================================
@@ -230,8 +683,8 @@ type Customer struct {
 	ExternalID    string            `json:"external_id,omitempty"`
 	Metadata      map[string]string `json:"metadata,omitempty"`
 	Subscriptions []Subscription    `json:"subscriptions,omitempty"`
-	CreatedAt     time.Time         `json:"created_at"`
-	UpdatedAt     time.Time         `json:"updated_at,omitempty"`
+	CreatedAt     Time              `json:"created_at"`
+	UpdatedAt     Time              `json:"updated_at,omitempty"`
 }
 
 // CreateCustomerParams are the parameters for creating a customer.
@@ -243,9 +696,10 @@ type CreateCustomerParams struct {
 }
 
 // CreateCustomer creates a new customer.
-func (s *BillingService) CreateCustomer(ctx context.Context, params *CreateCustomerParams) (*Customer, error) {
+func (s *BillingService) CreateCustomer(ctx context.Context, params *CreateCustomerParams, opts ...RequestOption) (*Customer, error) {
 	var customer Customer
-	err := s.client.request(ctx, "POST", "/billing/v1/customers", params, &customer)
+	ctx, path := s.op(ctx, "CreateCustomer", "/billing/v1/customers")
+	err := s.client.request(ctx, "POST", path, params, &customer, opts...)
 	if err != nil {
 		return nil, err
 	}
@@ -255,32 +709,194 @@ func (s *BillingService) CreateCustomer(ctx context.Context, params *CreateCusto
 // CreateCustomerForUser creates a billing customer for a user.
 // The customer's ExternalID is set to "user:{userID}" for cross-referencing.
 // Returns the customer ID.
-func (s *BillingService) CreateCustomerForUser(ctx context.Context, userID int, email, name string) (string, error) {
+func (s *BillingService) CreateCustomerForUser(ctx context.Context, userID int, email, name string, opts ...RequestOption) (string, error) {
 	customer, err := s.CreateCustomer(ctx, &CreateCustomerParams{
 		Email:      email,
 		Name:       name,
 		ExternalID: fmt.Sprintf("user:%d", userID),
-	})
+	}, opts...)
 	if err != nil {
 		return "", fmt.Errorf("failed to create billing customer for user: %w", err)
 	}
 	return customer.ID, nil
 }
 
+// ErrMultipleCustomers is returned by lookups that expect a single customer
+// when more than one matches.
+var ErrMultipleCustomers = errors.New("tedo: multiple customers match")
+
+// GetCustomerByExternalID retrieves the customer with the given external ID.
+// It returns an error matching ErrNotFound if there is none, and
+// ErrMultipleCustomers if there are several.
+func (s *BillingService) GetCustomerByExternalID(ctx context.Context, externalID string, opts ...RequestOption) (*Customer, error) {
+	return s.findCustomer(ctx, "GetCustomerByExternalID", "external_id", externalID, opts...)
+}
+
+// GetCustomerByEmail retrieves the customer with the given email address.
+// It returns an error matching ErrNotFound if there is none, and
+// ErrMultipleCustomers if there are several.
+func (s *BillingService) GetCustomerByEmail(ctx context.Context, email string, opts ...RequestOption) (*Customer, error) {
+	return s.findCustomer(ctx, "GetCustomerByEmail", "email", email, opts...)
+}
+
+// GetCustomerForUser retrieves the customer created for a user by
+// CreateCustomerForUser, i.e. the one with ExternalID "user:{userID}".
+func (s *BillingService) GetCustomerForUser(ctx context.Context, userID int, opts ...RequestOption) (*Customer, error) {
+	return s.GetCustomerByExternalID(ctx, fmt.Sprintf("user:%d", userID), opts...)
+}
+
+// findCustomer lists the customers whose field equals value and returns the
+// only match.
+func (s *BillingService) findCustomer(ctx context.Context, name, field, value string, opts ...RequestOption) (*Customer, error) {
+	if value == "" {
+		return nil, fmt.Errorf("%w: %s is required", ErrValidation, field)
+	}
+	query := queryParams{}
+	query.set(field, value)
+	ctx, path := s.op(ctx, name, "/billing/v1/customers")
+
+	var list CustomerList
+	err := s.client.request(ctx, "GET", query.path(path), nil, &list, opts...)
+	if err != nil {
+		return nil, err
+	}
+	switch len(list.Customers) {
+	case 0:
+		return nil, fmt.Errorf("%w: no customer with %s %q", ErrNotFound, field, value)
+	case 1:
+		return &list.Customers[0], nil
+	default:
+		return nil, fmt.Errorf("%w: %d customers with %s %q", ErrMultipleCustomers, len(list.Customers), field, value)
+	}
+}
+
+// UpsertCustomerParams are the parameters for UpsertCustomer.
+type UpsertCustomerParams struct {
+	// ExternalID identifies the customer. It is required.
+	ExternalID string
+
+	// Email and Name are set on create, and on update when not empty.
+	Email string
+	Name  string
+
+	// Metadata is merged into the customer's existing metadata: keys given
+	// here are set and other keys are left as they are.
+	Metadata map[string]string
+}
+
+// UpsertCustomer creates the customer with params.ExternalID, or updates it
+// if it already exists. It returns the customer and true if it was created,
+// or false if it was updated.
+//
+// The lookup and the create are separate requests. If a concurrent caller
+// creates the customer in between, the create fails with a 409 Conflict and
+// UpsertCustomer updates the customer that won instead.
+func (s *BillingService) UpsertCustomer(ctx context.Context, params *UpsertCustomerParams, opts ...RequestOption) (*Customer, bool, error) {
+	if params == nil {
+		return nil, false, fmt.Errorf("%w: params are required", ErrValidation)
+	}
+	if params.ExternalID == "" {
+		return nil, false, fmt.Errorf("%w: external_id is required", ErrValidation)
+	}
+
+	customer, err := s.GetCustomerByExternalID(ctx, params.ExternalID, opts...)
+	if IsNotFound(err) {
+		customer, err = s.CreateCustomer(ctx, &CreateCustomerParams{
+			Email:      params.Email,
+			Name:       params.Name,
+			ExternalID: params.ExternalID,
+			Metadata:   params.Metadata,
+		}, opts...)
+		if err == nil {
+			return customer, true, nil
+		}
+		if !IsConflict(err) {
+			return nil, false, err
+		}
+		customer, err = s.GetCustomerByExternalID(ctx, params.ExternalID, opts...)
+	}
+	if err != nil {
+		return nil, false, err
+	}
+
+	update := UpdateCustomerParams{}
+	changed := false
+	if params.Email != "" && params.Email != customer.Email {
+		update.Email = &params.Email
+		changed = true
+	}
+	if params.Name != "" && params.Name != customer.Name {
+		update.Name = &params.Name
+		changed = true
+	}
+	for k, v := range params.Metadata {
+		if existing, ok := customer.Metadata[k]; !ok || existing != v {
+			// Send the merged map so that the result doesn't depend on
+			// whether the API merges or replaces metadata.
+			update.Metadata = maps.Clone(customer.Metadata)
+			if update.Metadata == nil {
+				update.Metadata = map[string]string{}
+			}
+			maps.Copy(update.Metadata, params.Metadata)
+			changed = true
+			break
+		}
+	}
+	if !changed {
+		return customer, false, nil
+	}
+
+	customer, err = s.UpdateCustomer(ctx, customer.ID, &update, opts...)
+	if err != nil {
+		return nil, false, err
+	}
+	return customer, false, nil
+}
+
 // GetCustomer retrieves a customer by ID.
-func (s *BillingService) GetCustomer(ctx context.Context, id string) (*Customer, error) {
+func (s *BillingService) GetCustomer(ctx context.Context, id string, opts ...RequestOption) (*Customer, error) {
 	var customer Customer
-	err := s.client.request(ctx, "GET", "/billing/v1/customers/"+id, nil, &customer)
+	ctx, path, err := s.route(ctx, "GetCustomer", "/billing/v1/customers/{customerID}", id)
+	if err != nil {
+		return nil, err
+	}
+	err = s.client.request(ctx, "GET", path, nil, &customer, opts...)
 	if err != nil {
 		return nil, err
 	}
 	return &customer, nil
 }
 
-// ListCustomersParams are the parameters for listing customers.
+// GetCustomerRaw retrieves a customer by ID, returning both the decoded
+// customer and the exact JSON the server sent, e.g. for audit storage.
+func (s *BillingService) GetCustomerRaw(ctx context.Context, id string, opts ...RequestOption) (*Customer, json.RawMessage, error) {
+	var raw json.RawMessage
+	ctx, path, err := s.route(ctx, "GetCustomerRaw", "/billing/v1/customers/{customerID}", id)
+	if err != nil {
+		return nil, nil, err
+	}
+	err = s.client.request(ctx, "GET", path, nil, &raw, opts...)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var customer Customer
+	if err := json.Unmarshal(raw, &customer); err != nil {
+		return nil, nil, fmt.Errorf("decode response: %w", err)
+	}
+	return &customer, raw, nil
+}
+
+// ListCustomersParams are the parameters for listing customers. Zero
+// fields don't filter. NewCustomerQuery builds them fluently.
 type ListCustomersParams struct {
 	Limit  int    `json:"limit,omitempty"`
 	Cursor string `json:"cursor,omitempty"`
+
+	Email        string    `json:"email,omitempty"`         // only customers with this email address
+	ExternalID   string    `json:"external_id,omitempty"`   // only customers with this external ID
+	UpdatedSince time.Time `json:"updated_since,omitempty"` // only customers updated at or after this time
+	Expand       []string  `json:"expand,omitempty"`        // related objects to include, e.g. "subscriptions"
 }
 
 // CustomerList is a paginated list of customers.
@@ -291,44 +907,142 @@ type CustomerList struct {
 }
 
 // ListCustomers lists all customers.
-func (s *BillingService) ListCustomers(ctx context.Context, params *ListCustomersParams) (*CustomerList, error) {
-	path := "/billing/v1/customers"
+func (s *BillingService) ListCustomers(ctx context.Context, params *ListCustomersParams, opts ...RequestOption) (*CustomerList, error) {
+	query := queryParams{}
 	if params != nil {
-		query := ""
-		if params.Limit > 0 {
-			query += fmt.Sprintf("limit=%d", params.Limit)
-		}
-		if params.Cursor != "" {
-			if query != "" {
-				query += "&"
-			}
-			query += "cursor=" + params.Cursor
-		}
-		if query != "" {
-			path += "?" + query
-		}
+		query.setInt("limit", params.Limit)
+		query.set("cursor", params.Cursor)
+		query.set("email", params.Email)
+		query.set("external_id", params.ExternalID)
+		query.setTime("updated_since", params.UpdatedSince)
+		query.set("expand", strings.Join(params.Expand, ","))
 	}
+	ctx, path := s.op(ctx, "ListCustomers", "/billing/v1/customers")
 
 	var list CustomerList
-	err := s.client.request(ctx, "GET", path, nil, &list)
+	err := s.client.request(ctx, "GET", query.path(path), nil, &list, opts...)
 	if err != nil {
 		return nil, err
 	}
 	return &list, nil
 }
 
+// CustomerIter walks all customers, fetching pages as needed. Create one
+// with ListCustomersIter:
+//
+//	it := client.Billing.ListCustomersIter(ctx, &tedo.ListCustomersParams{Limit: 100})
+//	for it.Next() {
+//		customer := it.Customer()
+//		// ...
+//	}
+//	if err := it.Err(); err != nil {
+//		// ...
+//	}
+type CustomerIter struct {
+	ctx    context.Context
+	s      *BillingService
+	params ListCustomersParams
+	opts   []RequestOption
+
+	page     []Customer
+	customer *Customer
+	cursors  cursorGuard
+	last     bool // no pages after page
+	err      error
+}
+
+// ListCustomersIter returns an iterator over all customers, starting at
+// params.Cursor and fetching params.Limit customers per page.
+func (s *BillingService) ListCustomersIter(ctx context.Context, params *ListCustomersParams, opts ...RequestOption) *CustomerIter {
+	it := &CustomerIter{ctx: ctx, s: s, opts: opts}
+	if params != nil {
+		it.params = *params
+	}
+	it.cursors = newCursorGuard(it.params.Cursor)
+	return it
+}
+
+// Next advances to the next customer, fetching the next page when the
+// current one is used up. It returns false when there are no more
+// customers, when ctx is canceled, or when fetching a page fails; Err tells
+// these apart. Every error ends the iteration, whatever WithStopOnError
+// says, since the next page can't be reached.
+func (it *CustomerIter) Next() bool {
+	if it.err != nil {
+		return false
+	}
+	if err := it.ctx.Err(); err != nil {
+		it.err = err
+		return false
+	}
+	for len(it.page) == 0 {
+		if it.last {
+			return false
+		}
+		list, err := it.s.ListCustomers(it.ctx, &it.params, it.opts...)
+		if err == nil {
+			err = it.cursors.next(list.NextCursor)
+		}
+		if err != nil {
+			it.err = err
+			return false
+		}
+		it.page = list.Customers
+		it.params.Cursor = list.NextCursor
+		it.last = list.NextCursor == ""
+	}
+	it.customer = &it.page[0]
+	it.page = it.page[1:]
+	return true
+}
+
+// Customer returns the current customer. It is only valid after Next
+// returned true.
+func (it *CustomerIter) Customer() *Customer {
+	return it.customer
+}
+
+// Err returns the error that stopped the iteration, or nil if it ran to
+// completion.
+func (it *CustomerIter) Err() error {
+	return it.err
+}
+
 // UpdateCustomerParams are the parameters for updating a customer.
+//
+// A nil or empty Metadata map is omitted from the request and leaves the
+// customer's metadata unchanged. Set ClearMetadata to remove all metadata.
 type UpdateCustomerParams struct {
 	Email      *string           `json:"email,omitempty"`
 	Name       *string           `json:"name,omitempty"`
 	ExternalID *string           `json:"external_id,omitempty"`
 	Metadata   map[string]string `json:"metadata,omitempty"`
+
+	// ClearMetadata sends an explicit empty metadata object, which removes
+	// all metadata from the customer. Metadata is ignored when it is set.
+	ClearMetadata bool `json:"-"`
+}
+
+// MarshalJSON implements json.Marshaler.
+func (p UpdateCustomerParams) MarshalJSON() ([]byte, error) {
+	type params UpdateCustomerParams
+	if !p.ClearMetadata {
+		return json.Marshal(params(p))
+	}
+	return json.Marshal(struct {
+		params
+		Metadata map[string]string `json:"metadata"`
+	}{params(p), map[string]string{}})
 }
 
 // UpdateCustomer updates a customer.
-func (s *BillingService) UpdateCustomer(ctx context.Context, id string, params *UpdateCustomerParams) (*Customer, error) {
+func (s *BillingService) UpdateCustomer(ctx context.Context, id string, params *UpdateCustomerParams, opts ...RequestOption) (*Customer, error) {
 	var customer Customer
-	err := s.client.request(ctx, "PATCH", "/billing/v1/customers/"+id, params, &customer)
+	ctx, path, err := s.route(ctx, "UpdateCustomer", "/billing/v1/customers/{customerID}", id)
+	if err != nil {
+		return nil, err
+	}
+	err = s.client.request(ctx, "PATCH", path, params, &customer, opts...)
 	if err != nil {
 		return nil, err
 	}
@@ -336,25 +1050,121 @@ func (s *BillingService) UpdateCustomer(ctx context.Context, id string, params *
 }
 
 // DeleteCustomer deletes a customer.
-func (s *BillingService) DeleteCustomer(ctx context.Context, id string) error {
-	return s.client.request(ctx, "DELETE", "/billing/v1/customers/"+id, nil, nil)
+func (s *BillingService) DeleteCustomer(ctx context.Context, id string, opts ...RequestOption) error {
+	ctx, path, err := s.route(ctx, "DeleteCustomer", "/billing/v1/customers/{customerID}", id)
+	if err != nil {
+		return err
+	}
+	return s.client.request(ctx, "DELETE", path, nil, nil, opts...)
 }
 
 // ============================================================
 // SUBSCRIPTIONS
 // ============================================================
 
+// SubscriptionStatus is the lifecycle state of a subscription.
+type SubscriptionStatus string
+
+// Subscription statuses.
+const (
+	SubscriptionStatusIncomplete SubscriptionStatus = "incomplete"
+	SubscriptionStatusTrialing   SubscriptionStatus = "trialing"
+	SubscriptionStatusActive     SubscriptionStatus = "active"
+	SubscriptionStatusPastDue    SubscriptionStatus = "past_due"
+	SubscriptionStatusPaused     SubscriptionStatus = "paused"
+	SubscriptionStatusCanceled   SubscriptionStatus = "canceled"
+)
+
+// subscriptionTransitions lists the statuses reachable from each status.
+// A canceled subscription can only return to active, by
+// ReactivateSubscription before its ReactivationDeadline.
+var subscriptionTransitions = map[SubscriptionStatus][]SubscriptionStatus{
+	SubscriptionStatusIncomplete: {SubscriptionStatusActive, SubscriptionStatusTrialing, SubscriptionStatusCanceled},
+	SubscriptionStatusTrialing:   {SubscriptionStatusActive, SubscriptionStatusPastDue, SubscriptionStatusPaused, SubscriptionStatusCanceled},
+	SubscriptionStatusActive:     {SubscriptionStatusPastDue, SubscriptionStatusPaused, SubscriptionStatusCanceled},
+	SubscriptionStatusPastDue:    {SubscriptionStatusActive, SubscriptionStatusCanceled},
+	SubscriptionStatusPaused:     {SubscriptionStatusActive, SubscriptionStatusCanceled},
+	SubscriptionStatusCanceled:   {SubscriptionStatusActive},
+}
+
+// CanTransition reports whether a subscription may move from one status to
+// another. It is a local check for avoiding requests the API would reject;
+// the server remains authoritative.
+func CanTransition(from, to SubscriptionStatus) bool {
+	for _, next := range subscriptionTransitions[from] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
 // Subscription represents a billing subscription.
 type Subscription struct {
-	ID         string            `json:"id"`
-	CustomerID string            `json:"customer_id"`
-	PriceID    string            `json:"price_id"`
-	Status     string            `json:"status"` // active, canceled, past_due
-	Quantity   int               `json:"quantity,omitempty"`
-	StartedAt  time.Time         `json:"started_at"`
-	CanceledAt *time.Time        `json:"canceled_at,omitempty"`
-	Metadata   map[string]string `json:"metadata,omitempty"`
-	CreatedAt  time.Time         `json:"created_at"`
+	ID                   string            `json:"id"`
+	CustomerID           string            `json:"customer_id"`
+	PriceID              string            `json:"price_id"`
+	PlanKey              string            `json:"plan_key,omitempty"`
+	PriceKey             string            `json:"price_key,omitempty"`
+	Status               string            `json:"status"` // see SubscriptionStatus
+	Quantity             int               `json:"quantity,omitempty"`
+	PaymentMethodID      string            `json:"payment_method_id,omitempty"` // resolved, may be inherited from the customer
+	StartedAt            Time              `json:"started_at"`
+	CurrentPeriodEnd     Time              `json:"current_period_end,omitempty"`
+	CancelAtPeriodEnd    bool              `json:"cancel_at_period_end,omitempty"` // cancels at CurrentPeriodEnd, active until then
+	CancellationReason   string            `json:"cancellation_reason,omitempty"`
+	CanceledAt           *Time             `json:"canceled_at,omitempty"`
+	ReactivationDeadline *Time             `json:"reactivation_deadline,omitempty"` // end of the grace window to reactivate a canceled subscription
+	PausedAt             *Time             `json:"paused_at,omitempty"`
+	ResumesAt            *Time             `json:"resumes_at,omitempty"`  // scheduled resume of a paused subscription
+	PauseUsage           bool              `json:"pause_usage,omitempty"` // usage is rejected while paused
+	Metadata             map[string]string `json:"metadata,omitempty"`
+	CreatedAt            Time              `json:"created_at"`
+}
+
+// builtinPlans maps the built-in price keys to their plan keys.
+var builtinPlans = map[string]string{
+	GuestPriceKey: GuestPlanKey,
+	FreePriceKey:  FreePlanKey,
+	BasicPriceKey: BasicPlanKey,
+}
+
+// BuiltinPlanKey returns GuestPlanKey, FreePlanKey or BasicPlanKey if the
+// subscription is on one of Tedo's built-in prices, and false for custom
+// plans.
+func (s *Subscription) BuiltinPlanKey() (string, bool) {
+	planKey, ok := builtinPlans[s.PriceKey]
+	return planKey, ok
+}
+
+// CanReactivate reports whether ReactivateSubscription can restore the
+// subscription: a pending cancellation can always be undone, and a canceled
+// subscription until its ReactivationDeadline by the local clock. Past that
+// a new subscription is required. The server has the final say, so a
+// subscription at the edge of the window may still fail with
+// ErrReactivationExpired.
+func (s *Subscription) CanReactivate() bool {
+	return s.canReactivateAt(time.Now())
+}
+
+func (s *Subscription) canReactivateAt(now time.Time) bool {
+	if s.Status != string(SubscriptionStatusCanceled) {
+		return s.CancelAtPeriodEnd
+	}
+	return s.ReactivationDeadline != nil && now.Before(s.ReactivationDeadline.Time)
+}
+
+// UsagePaused reports whether the subscription is paused with
+// PauseSubscriptionParams.PauseUsage, so RecordUsage would be rejected.
+// Metering code can check it to skip recording while paused.
+func (s *Subscription) UsagePaused() bool {
+	return s.Status == string(SubscriptionStatusPaused) && s.PauseUsage
+}
+
+// IsBuiltinPlan reports whether the subscription is on a built-in plan.
+func (s *Subscription) IsBuiltinPlan() bool {
+	_, ok := s.BuiltinPlanKey()
+	return ok
 }
 
 // CreateSubscriptionParams are the parameters for creating a subscription.
@@ -366,52 +1176,106 @@ type CreateSubscriptionParams struct {
 	InitialStatus string            `json:"initial_status,omitempty"` // "incomplete" to defer activation until payment
 	Quantity      int               `json:"quantity,omitempty"`
 	Metadata      map[string]string `json:"metadata,omitempty"`
+
+	// PaymentMethodID overrides the payment method to charge. When nil, the
+	// subscription uses the customer's default payment method.
+	PaymentMethodID *string `json:"payment_method_id,omitempty"`
 }
 
 // CreateSubscription creates a new subscription.
-func (s *BillingService) CreateSubscription(ctx context.Context, params *CreateSubscriptionParams) (*Subscription, error) {
+func (s *BillingService) CreateSubscription(ctx context.Context, params *CreateSubscriptionParams, opts ...RequestOption) (*Subscription, error) {
 	var subscription Subscription
-	err := s.client.request(ctx, "POST", "/billing/v1/subscriptions", params, &subscription)
+	ctx, path := s.op(ctx, "CreateSubscription", "/billing/v1/subscriptions")
+	err := s.client.request(ctx, "POST", path, params, &subscription, opts...)
 	if err != nil {
 		return nil, err
 	}
 	return &subscription, nil
 }
 
-// CreateSubscriptionForWorkspace creates a free-tier subscription for a workspace.
-// Returns the subscription ID.
-func (s *BillingService) CreateSubscriptionForWorkspace(ctx context.Context, customerID, workspaceID string) (string, error) {
-	return s.createSubscriptionWithPlan(ctx, customerID, FreePlanKey, FreePriceKey)
+// CreateSubscriptionIfNone creates a subscription only if the customer has no
+// active or trialing subscription. It returns the existing subscription and
+// false if there is one, or the new subscription and true. Subscriptions in
+// other states, such as past_due, paused or incomplete, don't prevent the
+// create. A nil params is rejected with an error matching ErrValidation.
+//
+// The check and the create are separate requests, so this is not
+// transactional: two concurrent callers can both create. Pair it with
+// WithIdempotencyKey (e.g. keyed on the signup) to close that window. If
+// the API rejects the create with a conflict (409) and the customer now has
+// an active or trialing subscription, that subscription is returned with
+// false; otherwise the conflict error is returned.
+func (s *BillingService) CreateSubscriptionIfNone(ctx context.Context, params *CreateSubscriptionParams, opts ...RequestOption) (*Subscription, bool, error) {
+	if params == nil {
+		return nil, false, fmt.Errorf("%w: params are required", ErrValidation)
+	}
+	existing, err := s.currentSubscription(ctx, params.CustomerID, opts...)
+	if err != nil || existing != nil {
+		return existing, false, err
+	}
+
+	subscription, err := s.CreateSubscription(ctx, params, opts...)
+	if IsConflict(err) {
+		if existing, _ := s.currentSubscription(ctx, params.CustomerID, opts...); existing != nil {
+			return existing, false, nil
+		}
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	return subscription, true, nil
+}
+
+// currentSubscription returns the customer's first active or trialing
+// subscription, or nil if there is none.
+func (s *BillingService) currentSubscription(ctx context.Context, customerID string, opts ...RequestOption) (*Subscription, error) {
+	customer, err := s.GetCustomer(ctx, customerID, opts...)
+	if err != nil {
+		return nil, err
+	}
+	for i := range customer.Subscriptions {
+		switch SubscriptionStatus(customer.Subscriptions[i].Status) {
+		case SubscriptionStatusActive, SubscriptionStatusTrialing:
+			return &customer.Subscriptions[i], nil
+		}
+	}
+	return nil, nil
+}
+
+// CreateSubscriptionForWorkspace creates a free-tier subscription for a workspace.
+// Returns the subscription ID.
+func (s *BillingService) CreateSubscriptionForWorkspace(ctx context.Context, customerID, workspaceID string, opts ...RequestOption) (string, error) {
+	return s.createSubscriptionWithPlan(ctx, customerID, FreePlanKey, FreePriceKey, opts...)
 }
 
 // CreateSubscriptionForGuestWorkspace creates a guest-tier subscription (lower limits).
 // Returns the subscription ID.
-func (s *BillingService) CreateSubscriptionForGuestWorkspace(ctx context.Context, customerID, workspaceID string) (string, error) {
-	return s.createSubscriptionWithPlan(ctx, customerID, GuestPlanKey, GuestPriceKey)
+func (s *BillingService) CreateSubscriptionForGuestWorkspace(ctx context.Context, customerID, workspaceID string, opts ...RequestOption) (string, error) {
+	return s.createSubscriptionWithPlan(ctx, customerID, GuestPlanKey, GuestPriceKey, opts...)
 }
 
 // CreateSubscriptionForBasicPlan creates a basic paid subscription.
 // The subscription starts as "incomplete" and only becomes active after payment succeeds.
 // Returns the subscription ID.
-func (s *BillingService) CreateSubscriptionForBasicPlan(ctx context.Context, customerID string) (string, error) {
+func (s *BillingService) CreateSubscriptionForBasicPlan(ctx context.Context, customerID string, opts ...RequestOption) (string, error) {
 	subscription, err := s.CreateSubscription(ctx, &CreateSubscriptionParams{
 		CustomerID:    customerID,
 		PlanKey:       BasicPlanKey,
 		PriceKey:      BasicPriceKey,
-		InitialStatus: "incomplete",
-	})
+		InitialStatus: string(SubscriptionStatusIncomplete),
+	}, opts...)
 	if err != nil {
 		return "", fmt.Errorf("failed to create subscription: %w", err)
 	}
 	return subscription.ID, nil
 }
 
-func (s *BillingService) createSubscriptionWithPlan(ctx context.Context, customerID, planKey, priceKey string) (string, error) {
+func (s *BillingService) createSubscriptionWithPlan(ctx context.Context, customerID, planKey, priceKey string, opts ...RequestOption) (string, error) {
 	subscription, err := s.CreateSubscription(ctx, &CreateSubscriptionParams{
 		CustomerID: customerID,
 		PlanKey:    planKey,
 		PriceKey:   priceKey,
-	})
+	}, opts...)
 	if err != nil {
 		return "", fmt.Errorf("failed to create subscription: %w", err)
 	}
@@ -419,25 +1283,615 @@ func (s *BillingService) createSubscriptionWithPlan(ctx context.Context, custome
 }
 
 // GetSubscription retrieves a subscription by ID.
-func (s *BillingService) GetSubscription(ctx context.Context, id string) (*Subscription, error) {
+func (s *BillingService) GetSubscription(ctx context.Context, id string, opts ...RequestOption) (*Subscription, error) {
 	var subscription Subscription
-	err := s.client.request(ctx, "GET", "/billing/v1/subscriptions/"+id, nil, &subscription)
+	ctx, path, err := s.route(ctx, "GetSubscription", "/billing/v1/subscriptions/{subscriptionID}", id)
+	if err != nil {
+		return nil, err
+	}
+	err = s.client.request(ctx, "GET", path, nil, &subscription, opts...)
 	if err != nil {
 		return nil, err
 	}
 	return &subscription, nil
 }
 
-// CancelSubscription cancels a subscription.
-func (s *BillingService) CancelSubscription(ctx context.Context, id string) (*Subscription, error) {
+// ListSubscriptionsParams are the parameters for listing subscriptions. Zero
+// fields don't filter. NewSubscriptionQuery builds them fluently.
+type ListSubscriptionsParams struct {
+	CustomerID string
+	Status     SubscriptionStatus
+	PlanKey    string
+
+	// PeriodEndAfter and PeriodEndBefore keep subscriptions whose
+	// CurrentPeriodEnd is in [PeriodEndAfter, PeriodEndBefore).
+	PeriodEndAfter  time.Time
+	PeriodEndBefore time.Time
+
+	Limit  int
+	Cursor string
+}
+
+// SubscriptionList is a paginated list of subscriptions.
+type SubscriptionList struct {
+	Subscriptions []Subscription `json:"subscriptions"`
+	Total         int            `json:"total"`
+	NextCursor    string         `json:"next_cursor,omitempty"`
+}
+
+// ListSubscriptions lists subscriptions across customers, optionally
+// filtered by customer, status and plan. Use ListSubscriptionsIter to walk
+// all pages.
+func (s *BillingService) ListSubscriptions(ctx context.Context, params *ListSubscriptionsParams, opts ...RequestOption) (*SubscriptionList, error) {
+	query := queryParams{}
+	if params != nil {
+		query.set("customer_id", params.CustomerID)
+		query.set("status", string(params.Status))
+		query.set("plan_key", params.PlanKey)
+		query.setTime("current_period_end_gte", params.PeriodEndAfter)
+		query.setTime("current_period_end_lt", params.PeriodEndBefore)
+		query.setInt("limit", params.Limit)
+		query.set("cursor", params.Cursor)
+	}
+	ctx, path := s.op(ctx, "ListSubscriptions", "/billing/v1/subscriptions")
+
+	var list SubscriptionList
+	err := s.client.request(ctx, "GET", query.path(path), nil, &list, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &list, nil
+}
+
+// ListSubscriptionsForCustomer returns all of a customer's subscriptions,
+// fetching every page.
+func (s *BillingService) ListSubscriptionsForCustomer(ctx context.Context, customerID string, opts ...RequestOption) ([]Subscription, error) {
+	if customerID == "" {
+		return nil, fmt.Errorf("%w: customerID is required", ErrValidation)
+	}
+
+	var subscriptions []Subscription
+	it := s.ListSubscriptionsIter(ctx, &ListSubscriptionsParams{CustomerID: customerID}, opts...)
+	for it.Next() {
+		subscriptions = append(subscriptions, *it.Subscription())
+	}
+	if err := it.Err(); err != nil {
+		return nil, err
+	}
+	return subscriptions, nil
+}
+
+// SubscriptionIter walks all subscriptions matching a ListSubscriptionsParams,
+// fetching pages as needed. It is used like CustomerIter.
+type SubscriptionIter struct {
+	ctx    context.Context
+	s      *BillingService
+	params ListSubscriptionsParams
+	opts   []RequestOption
+
+	page         []Subscription
+	subscription *Subscription
+	cursors      cursorGuard
+	last         bool // no pages after page
+	err          error
+}
+
+// ListSubscriptionsIter returns an iterator over all subscriptions matching
+// params, starting at params.Cursor and fetching params.Limit subscriptions
+// per page.
+func (s *BillingService) ListSubscriptionsIter(ctx context.Context, params *ListSubscriptionsParams, opts ...RequestOption) *SubscriptionIter {
+	it := &SubscriptionIter{ctx: ctx, s: s, opts: opts}
+	if params != nil {
+		it.params = *params
+	}
+	it.cursors = newCursorGuard(it.params.Cursor)
+	return it
+}
+
+// Next advances to the next subscription, fetching the next page when the
+// current one is used up. It returns false when there are no more
+// subscriptions, when ctx is canceled, or when fetching a page fails; Err
+// tells these apart. Every error ends the iteration, whatever
+// WithStopOnError says, since the next page can't be reached.
+func (it *SubscriptionIter) Next() bool {
+	if it.err != nil {
+		return false
+	}
+	if err := it.ctx.Err(); err != nil {
+		it.err = err
+		return false
+	}
+	for len(it.page) == 0 {
+		if it.last {
+			return false
+		}
+		list, err := it.s.ListSubscriptions(it.ctx, &it.params, it.opts...)
+		if err == nil {
+			err = it.cursors.next(list.NextCursor)
+		}
+		if err != nil {
+			it.err = err
+			return false
+		}
+		it.page = list.Subscriptions
+		it.params.Cursor = list.NextCursor
+		it.last = list.NextCursor == ""
+	}
+	it.subscription = &it.page[0]
+	it.page = it.page[1:]
+	return true
+}
+
+// Subscription returns the current subscription. It is only valid after
+// Next returned true.
+func (it *SubscriptionIter) Subscription() *Subscription {
+	return it.subscription
+}
+
+// Err returns the error that stopped the iteration, or nil if it ran to
+// completion.
+func (it *SubscriptionIter) Err() error {
+	return it.err
+}
+
+// Proration behaviors for UpdateSubscriptionParams.ProrationBehavior.
+const (
+	ProrationCreateProrations = "create_prorations" // credit and charge the difference on the next invoice
+	ProrationNone             = "none"              // apply the change from the next period without prorating
+	ProrationAlwaysInvoice    = "always_invoice"    // prorate and invoice the difference immediately
+	ProrationCreditBalance    = "credit_balance"    // prorate, crediting unused time to the customer's balance instead of refunding it
+)
+
+// UpdateSubscriptionParams are the parameters for updating a subscription.
+// Nil fields are left unchanged. Changing the price keeps the subscription's
+// billing anchor and trial.
+type UpdateSubscriptionParams struct {
+	PriceID  *string           `json:"price_id,omitempty"`
+	PlanKey  *string           `json:"plan_key,omitempty"`
+	PriceKey *string           `json:"price_key,omitempty"`
+	Quantity *int              `json:"quantity,omitempty"`
+	Metadata map[string]string `json:"metadata,omitempty"`
+
+	// ProrationBehavior controls how a price or quantity change is billed:
+	// ProrationCreateProrations, ProrationNone, ProrationAlwaysInvoice or
+	// ProrationCreditBalance. Empty uses the API default.
+	ProrationBehavior string `json:"proration_behavior,omitempty"`
+
+	// EffectiveAt prorates the change as of this date instead of now, e.g.
+	// to align it with a contract date. It may lie in the future. The API
+	// rejects dates before the start of the current billing period; dates
+	// more than MaxEffectiveAtBackdate in the past are rejected with
+	// ErrValidation before sending. Preview the resulting proration with
+	// the same date in PreviewSubscriptionChangeParams.
+	EffectiveAt *time.Time `json:"effective_at,omitempty"`
+}
+
+// MaxEffectiveAtBackdate is how far in the past an EffectiveAt may lie. It
+// catches mistakes such as a wrong year; the API enforces the tighter limit
+// of the current billing period.
+const MaxEffectiveAtBackdate = 365 * 24 * time.Hour
+
+// validateEffectiveAt checks an EffectiveAt against MaxEffectiveAtBackdate.
+func validateEffectiveAt(effectiveAt *time.Time) error {
+	if effectiveAt != nil && time.Since(*effectiveAt) > MaxEffectiveAtBackdate {
+		return fmt.Errorf("%w: effective_at %s is more than a year in the past", ErrValidation, effectiveAt.Format(time.RFC3339))
+	}
+	return nil
+}
+
+// UpdateSubscription updates a subscription, e.g. to move it to another plan
+// or change its quantity.
+func (s *BillingService) UpdateSubscription(ctx context.Context, id string, params *UpdateSubscriptionParams, opts ...RequestOption) (*Subscription, error) {
+	if params != nil {
+		if err := validateEffectiveAt(params.EffectiveAt); err != nil {
+			return nil, err
+		}
+	}
 	var subscription Subscription
-	err := s.client.request(ctx, "DELETE", "/billing/v1/subscriptions/"+id, nil, &subscription)
+	ctx, path, err := s.route(ctx, "UpdateSubscription", "/billing/v1/subscriptions/{subscriptionID}", id)
+	if err != nil {
+		return nil, err
+	}
+	err = s.client.request(ctx, "PATCH", path, params, &subscription, opts...)
 	if err != nil {
 		return nil, err
 	}
 	return &subscription, nil
 }
 
+// DowngradeToFree moves a subscription to the built-in free plan
+// (FreePlanKey, FreePriceKey) with ProrationNone, so the customer gets no
+// credit for the unused part of the current period. It first checks that
+// the free price exists, failing with an error matched by ErrNotFound if
+// the workspace doesn't have it.
+func (s *BillingService) DowngradeToFree(ctx context.Context, subscriptionID string, opts ...RequestOption) (*Subscription, error) {
+	if strings.TrimSpace(subscriptionID) == "" {
+		return nil, fmt.Errorf("%w: subscriptionID is required", ErrValidation)
+	}
+	price, err := s.GetPriceByKey(ctx, FreePlanKey, FreePriceKey, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("find free plan: %w", err)
+	}
+	return s.UpdateSubscription(ctx, subscriptionID, &UpdateSubscriptionParams{
+		PriceID:           &price.ID,
+		ProrationBehavior: ProrationNone,
+	}, opts...)
+}
+
+// ChangeSubscriptionParams are the parameters for ChangeSubscription.
+type ChangeSubscriptionParams struct {
+	UpdateSubscriptionParams
+
+	// ExpandPrices fills the prices before and after the change into the
+	// result, as PriceChangeSummary needs. It costs a request for the
+	// subscription before the change and one for each price.
+	ExpandPrices bool
+}
+
+// ChangeSubscriptionResult is the outcome of ChangeSubscription.
+type ChangeSubscriptionResult struct {
+	Subscription *Subscription // after the change
+
+	// PreviousPrice and PreviousQuantity describe the subscription before
+	// the change and Price after it. They are only set with
+	// ChangeSubscriptionParams.ExpandPrices.
+	PreviousPrice    *Price
+	PreviousQuantity int
+	Price            *Price
+}
+
+// ErrPricesNotExpanded is returned by PriceChangeSummary for a result
+// obtained without ChangeSubscriptionParams.ExpandPrices.
+var ErrPricesNotExpanded = errors.New("tedo: prices not expanded")
+
+// PriceChangeSummary returns what the subscription cost per period before
+// and after the change, e.g. for a notification email. Quantities are
+// priced with CostFor, a zero quantity counting as one.
+func (r *ChangeSubscriptionResult) PriceChangeSummary() (oldAmount, newAmount Money, err error) {
+	if r.PreviousPrice == nil || r.Price == nil {
+		return Money{}, Money{}, ErrPricesNotExpanded
+	}
+	if oldAmount, err = r.PreviousPrice.CostFor(max(r.PreviousQuantity, 1)); err != nil {
+		return Money{}, Money{}, err
+	}
+	if newAmount, err = r.Price.CostFor(max(r.Subscription.Quantity, 1)); err != nil {
+		return Money{}, Money{}, err
+	}
+	return oldAmount, newAmount, nil
+}
+
+// ChangeSubscription changes a subscription like UpdateSubscription and,
+// with params.ExpandPrices, also returns its prices before and after the
+// change. If the change is applied but a price can't be fetched, the result
+// is returned together with the error.
+func (s *BillingService) ChangeSubscription(ctx context.Context, id string, params *ChangeSubscriptionParams, opts ...RequestOption) (*ChangeSubscriptionResult, error) {
+	if params == nil {
+		return nil, fmt.Errorf("%w: params are required", ErrValidation)
+	}
+	var previous *Subscription
+	if params.ExpandPrices {
+		var err error
+		if previous, err = s.GetSubscription(ctx, id, opts...); err != nil {
+			return nil, err
+		}
+	}
+	subscription, err := s.UpdateSubscription(ctx, id, &params.UpdateSubscriptionParams, opts...)
+	if err != nil {
+		return nil, err
+	}
+	result := &ChangeSubscriptionResult{Subscription: subscription}
+	if !params.ExpandPrices {
+		return result, nil
+	}
+
+	result.PreviousQuantity = previous.Quantity
+	previousPrice, err := s.GetPriceByID(ctx, previous.PriceID, opts...)
+	if err != nil {
+		return result, fmt.Errorf("expand previous price: %w", err)
+	}
+	price := previousPrice
+	if subscription.PriceID != previous.PriceID {
+		if price, err = s.GetPriceByID(ctx, subscription.PriceID, opts...); err != nil {
+			return result, fmt.Errorf("expand price: %w", err)
+		}
+	}
+	result.PreviousPrice, result.Price = previousPrice, price
+	return result, nil
+}
+
+// PauseSubscriptionParams are the parameters for pausing a subscription.
+type PauseSubscriptionParams struct {
+	// ResumesAt schedules the subscription to resume automatically. When
+	// nil, it stays paused until ResumeSubscription is called.
+	ResumesAt *time.Time `json:"resumes_at,omitempty"`
+
+	// PauseUsage also stops usage from accruing: while the subscription is
+	// paused, RecordUsage fails with an error matched by
+	// IsSubscriptionPaused. By default usage is still recorded.
+	PauseUsage bool `json:"pause_usage,omitempty"`
+}
+
+// PauseSubscription pauses a subscription: it moves to
+// SubscriptionStatusPaused and is not invoiced until resumed. params may be
+// nil. Pausing a canceled subscription fails with a conflict (see
+// IsConflict).
+func (s *BillingService) PauseSubscription(ctx context.Context, id string, params *PauseSubscriptionParams, opts ...RequestOption) (*Subscription, error) {
+	if params == nil {
+		params = &PauseSubscriptionParams{}
+	}
+
+	var subscription Subscription
+	ctx, path, err := s.route(ctx, "PauseSubscription", "/billing/v1/subscriptions/{subscriptionID}/pause", id)
+	if err != nil {
+		return nil, err
+	}
+	err = s.client.request(ctx, "POST", path, params, &subscription, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &subscription, nil
+}
+
+// ResumeSubscription resumes a paused subscription immediately.
+func (s *BillingService) ResumeSubscription(ctx context.Context, id string, opts ...RequestOption) (*Subscription, error) {
+	var subscription Subscription
+	ctx, path, err := s.route(ctx, "ResumeSubscription", "/billing/v1/subscriptions/{subscriptionID}/resume", id)
+	if err != nil {
+		return nil, err
+	}
+	err = s.client.request(ctx, "POST", path, nil, &subscription, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &subscription, nil
+}
+
+// PreviewSubscriptionChangeParams describe a change to preview with
+// PreviewSubscriptionChange. Set the target price by PriceID, or by PlanKey
+// and optionally PriceKey; zero fields keep the current value.
+type PreviewSubscriptionChangeParams struct {
+	PriceID  string `json:"price_id,omitempty"`
+	PlanKey  string `json:"plan_key,omitempty"`
+	PriceKey string `json:"price_key,omitempty"`
+	Quantity int    `json:"quantity,omitempty"`
+
+	// EffectiveAt prorates as of this date instead of now; see
+	// UpdateSubscriptionParams.EffectiveAt. The preview's ProrationDate
+	// reports the date used.
+	EffectiveAt *time.Time `json:"effective_at,omitempty"`
+}
+
+// SubscriptionChangePreview is what a subscription change would cost. The
+// amounts are the exact cents the API will charge, already rounded as
+// described by Rounding; display them as they are rather than summing or
+// recomputing LineItems.
+type SubscriptionChangePreview struct {
+	AmountDueNow  int                          `json:"amount_due_now"`     // in cents, negative for a net credit; equals NetAmount
+	GrossAmount   int                          `json:"gross_amount"`       // charges before credits, in cents
+	NetAmount     int                          `json:"net_amount"`         // charges minus credits, in cents
+	Rounding      string                       `json:"rounding,omitempty"` // see Rounding constants
+	Currency      string                       `json:"currency"`
+	ProrationDate Time                         `json:"proration_date"`
+	LineItems     []SubscriptionChangeLineItem `json:"line_items"`
+}
+
+// Rounding modes the API applies when prorating to whole cents. Each line
+// item is rounded on its own, and the totals are sums of rounded items.
+const (
+	RoundingHalfUp   = "half_up"   // halves round away from zero
+	RoundingHalfEven = "half_even" // halves round to the even cent
+)
+
+// Gross returns GrossAmount as Money in the preview's currency.
+func (p *SubscriptionChangePreview) Gross() Money {
+	return Money{Amount: p.GrossAmount, Currency: p.Currency}
+}
+
+// Net returns NetAmount as Money in the preview's currency.
+func (p *SubscriptionChangePreview) Net() Money {
+	return Money{Amount: p.NetAmount, Currency: p.Currency}
+}
+
+// SubscriptionChangeLineItem is one charge or credit in a
+// SubscriptionChangePreview.
+type SubscriptionChangeLineItem struct {
+	Description string `json:"description"`
+	Amount      int    `json:"amount"` // in cents, negative for credits
+	Quantity    int    `json:"quantity,omitempty"`
+	PriceID     string `json:"price_id,omitempty"`
+	Proration   bool   `json:"proration,omitempty"`
+}
+
+// PreviewSubscriptionChange computes what changing a subscription's price or
+// quantity would cost as of now, e.g. to show the amount due before an
+// upgrade. It changes nothing; apply the change with UpdateSubscription.
+func (s *BillingService) PreviewSubscriptionChange(ctx context.Context, subscriptionID string, params *PreviewSubscriptionChangeParams, opts ...RequestOption) (*SubscriptionChangePreview, error) {
+	if params != nil {
+		if err := validateEffectiveAt(params.EffectiveAt); err != nil {
+			return nil, err
+		}
+	}
+	var preview SubscriptionChangePreview
+	ctx, path, err := s.route(ctx, "PreviewSubscriptionChange", "/billing/v1/subscriptions/{subscriptionID}/preview", subscriptionID)
+	if err != nil {
+		return nil, err
+	}
+	err = s.client.request(ctx, "POST", path, params, &preview, append([]RequestOption{readOnly}, opts...)...)
+	if err != nil {
+		return nil, err
+	}
+	return &preview, nil
+}
+
+// CancelSubscriptionParams are the parameters for canceling a subscription.
+type CancelSubscriptionParams struct {
+	// AtPeriodEnd keeps the subscription active until CurrentPeriodEnd and
+	// cancels it then, instead of canceling immediately.
+	AtPeriodEnd bool
+
+	// Reason is recorded with the cancellation.
+	Reason string
+}
+
+// CancelSubscription cancels a subscription. With nil params it cancels
+// immediately. With params.AtPeriodEnd the returned subscription stays
+// active with CancelAtPeriodEnd set, and ReactivateSubscription can undo the
+// cancellation until CurrentPeriodEnd.
+func (s *BillingService) CancelSubscription(ctx context.Context, id string, params *CancelSubscriptionParams, opts ...RequestOption) (*Subscription, error) {
+	query := queryParams{}
+	if params != nil {
+		if params.AtPeriodEnd {
+			query.set("at_period_end", "true")
+		}
+		query.set("reason", params.Reason)
+	}
+
+	var subscription Subscription
+	ctx, path, err := s.route(ctx, "CancelSubscription", "/billing/v1/subscriptions/{subscriptionID}", id)
+	if err != nil {
+		return nil, err
+	}
+	err = s.client.request(ctx, "DELETE", query.path(path), nil, &subscription, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &subscription, nil
+}
+
+// ReactivateSubscription undoes a pending cancellation made with
+// CancelSubscriptionParams.AtPeriodEnd, keeping the subscription's billing
+// anchor and trial. A subscription that is already canceled can be
+// reactivated until its ReactivationDeadline; after that it fails with a
+// conflict matched by ErrReactivationExpired, and a new subscription is
+// required. Use Subscription.CanReactivate to decide which to offer.
+func (s *BillingService) ReactivateSubscription(ctx context.Context, id string, opts ...RequestOption) (*Subscription, error) {
+	var subscription Subscription
+	ctx, path, err := s.route(ctx, "ReactivateSubscription", "/billing/v1/subscriptions/{subscriptionID}/reactivate", id)
+	if err != nil {
+		return nil, err
+	}
+	err = s.client.request(ctx, "POST", path, nil, &subscription, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &subscription, nil
+}
+
+// PreviewCancellationRefund gets the prorated credit for the unused part of
+// the current period if the subscription were canceled immediately,
+// without canceling it. The amount is zero for subscriptions that have
+// already expired or whose price is not refundable.
+//
+// The API computes the proration and rounds it to the currency's minor
+// unit, so the result matches the refund issued on cancellation.
+func (s *BillingService) PreviewCancellationRefund(ctx context.Context, subscriptionID string, opts ...RequestOption) (*Money, error) {
+	var refund Money
+	ctx, path, err := s.route(ctx, "PreviewCancellationRefund", "/billing/v1/subscriptions/{subscriptionID}/cancellation-refund", subscriptionID)
+	if err != nil {
+		return nil, err
+	}
+	err = s.client.request(ctx, "GET", path, nil, &refund, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &refund, nil
+}
+
+// TransferSubscription moves a subscription to a different customer, e.g.
+// when a workspace changes ownership, and returns it with the new CustomerID.
+// An empty newCustomerID is rejected with an error matching ErrValidation.
+func (s *BillingService) TransferSubscription(ctx context.Context, subscriptionID, newCustomerID string, opts ...RequestOption) (*Subscription, error) {
+	if strings.TrimSpace(newCustomerID) == "" {
+		return nil, fmt.Errorf("%w: newCustomerID is required", ErrValidation)
+	}
+	params := struct {
+		CustomerID string `json:"customer_id"`
+	}{newCustomerID}
+
+	var subscription Subscription
+	ctx, path, err := s.route(ctx, "TransferSubscription", "/billing/v1/subscriptions/{subscriptionID}/transfer", subscriptionID)
+	if err != nil {
+		return nil, err
+	}
+	err = s.client.request(ctx, "POST", path, params, &subscription, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &subscription, nil
+}
+
+// SubscriptionStats are subscription counts aggregated by the server.
+type SubscriptionStats struct {
+	Total    int                       `json:"total"`
+	ByStatus map[string]int            `json:"by_status"`
+	ByPlan   map[string]map[string]int `json:"by_plan,omitempty"` // plan key -> status -> count
+}
+
+// GetSubscriptionStatsParams are the parameters for getting subscription stats.
+type GetSubscriptionStatsParams struct {
+	GroupBy string // optional; "plan" also populates ByPlan
+}
+
+// GetSubscriptionStats gets subscription counts by status (and optionally by
+// plan) without paginating through every subscription.
+func (s *BillingService) GetSubscriptionStats(ctx context.Context, params *GetSubscriptionStatsParams, opts ...RequestOption) (*SubscriptionStats, error) {
+	query := queryParams{}
+	if params != nil {
+		query.set("group_by", params.GroupBy)
+	}
+	ctx, path := s.op(ctx, "GetSubscriptionStats", "/billing/v1/subscriptions/stats")
+
+	var stats SubscriptionStats
+	err := s.client.request(ctx, "GET", query.path(path), nil, &stats, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &stats, nil
+}
+
+// QuantityChange is a change to a subscription's quantity (e.g. seats).
+type QuantityChange struct {
+	OldQuantity     int       `json:"old_quantity"`
+	NewQuantity     int       `json:"new_quantity"`
+	EffectiveAt     time.Time `json:"effective_at"`
+	ProrationAmount int       `json:"proration_amount"` // in cents, negative for credits
+}
+
+// ListSubscriptionQuantityChanges lists the quantity history of a
+// subscription, oldest first, fetching every page.
+func (s *BillingService) ListSubscriptionQuantityChanges(ctx context.Context, subscriptionID string, opts ...RequestOption) ([]QuantityChange, error) {
+	ctx, base, err := s.route(ctx, "ListSubscriptionQuantityChanges", "/billing/v1/subscriptions/{subscriptionID}/quantity-changes", subscriptionID)
+	if err != nil {
+		return nil, err
+	}
+
+	var changes []QuantityChange
+	cursor := ""
+	cursors := newCursorGuard("")
+	for {
+		query := queryParams{}
+		query.set("cursor", cursor)
+		path := query.path(base)
+
+		var page struct {
+			QuantityChanges []QuantityChange `json:"quantity_changes"`
+			NextCursor      string           `json:"next_cursor,omitempty"`
+		}
+		if err := s.client.request(ctx, "GET", path, nil, &page, opts...); err != nil {
+			return nil, err
+		}
+		changes = append(changes, page.QuantityChanges...)
+
+		if err := cursors.next(page.NextCursor); err != nil {
+			return nil, err
+		}
+		if page.NextCursor == "" {
+			return changes, nil
+		}
+		cursor = page.NextCursor
+	}
+}
+
 // ============================================================
 // CHECKOUT
 // ============================================================
@@ -449,30 +1903,117 @@ type CheckoutLink struct {
 	ExpiresAt   time.Time `json:"expires_at"`
 }
 
+// MaxRedirectStateLength is the maximum length in bytes of the State passed
+// through checkout and portal links.
+const MaxRedirectStateLength = 512
+
 // CreateCheckoutLinkParams are the parameters for creating a checkout link.
 type CreateCheckoutLinkParams struct {
 	ExpiresInHours int `json:"expires_in_hours,omitempty"`
+
+	// State is opaque data (e.g. the page the user came from) echoed back in
+	// the redirect after checkout. At most MaxRedirectStateLength bytes.
+	State string `json:"state,omitempty"`
 }
 
 // CreateCheckoutLink generates a checkout link for a subscription.
-func (s *BillingService) CreateCheckoutLink(ctx context.Context, subscriptionID string, params *CreateCheckoutLinkParams) (*CheckoutLink, error) {
+func (s *BillingService) CreateCheckoutLink(ctx context.Context, subscriptionID string, params *CreateCheckoutLinkParams, opts ...RequestOption) (*CheckoutLink, error) {
+	if params != nil {
+		if err := validateRedirectState(params.State); err != nil {
+			return nil, err
+		}
+	}
+
 	var link CheckoutLink
-	err := s.client.request(ctx, "POST", "/billing/v1/subscriptions/"+subscriptionID+"/checkout-link", params, &link)
+	ctx, path, err := s.route(ctx, "CreateCheckoutLink", "/billing/v1/subscriptions/{subscriptionID}/checkout-link", subscriptionID)
+	if err != nil {
+		return nil, err
+	}
+	err = s.client.request(ctx, "POST", path, params, &link, opts...)
 	if err != nil {
 		return nil, err
 	}
 	return &link, nil
 }
 
+// validateRedirectState rejects a State longer than MaxRedirectStateLength
+// with an error matching ErrValidation.
+func validateRedirectState(state string) error {
+	if len(state) > MaxRedirectStateLength {
+		return fmt.Errorf("%w: state is %d bytes, maximum is %d", ErrValidation, len(state), MaxRedirectStateLength)
+	}
+	return nil
+}
+
 // ============================================================
 // ENTITLEMENT CHECK
 // ============================================================
 
-// EntitlementCheck is the result of an entitlement check.
+// EntitlementCheck is the result of an entitlement check. Value holds the
+// decoded JSON value; use IntValue, BoolValue or StringValue to read it.
 type EntitlementCheck struct {
-	HasAccess bool   `json:"has_access"`
-	Value     any    `json:"value,omitempty"`
-	PlanName  string `json:"plan_name,omitempty"`
+	HasAccess      bool   `json:"has_access"`
+	Value          any    `json:"value,omitempty"`
+	PlanName       string `json:"plan_name,omitempty"`
+	PlanKey        string `json:"plan_key,omitempty"`
+	EntitlementKey string `json:"entitlement_key,omitempty"`
+	Source         string `json:"source,omitempty"` // see EntitlementSource constants
+}
+
+// Sources of an entitlement check result.
+const (
+	EntitlementSourcePlan     = "plan"     // granted or denied by the plan
+	EntitlementSourceOverride = "override" // a customer-specific override
+	EntitlementSourceDefault  = "default"  // no active plan defines the key
+)
+
+// UnmarshalJSON implements json.Unmarshaler. Numbers in Value decode as
+// json.Number rather than float64, so integers above 2^53 keep their exact
+// value.
+func (c *EntitlementCheck) UnmarshalJSON(data []byte) error {
+	type check EntitlementCheck
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	return dec.Decode((*check)(c))
+}
+
+// IntValue returns Value as an int. Integral numbers are converted exactly;
+// fractional or non-numeric values report false. Prefer these accessors over
+// asserting on Value directly.
+func (c *EntitlementCheck) IntValue() (int, bool) {
+	switch v := c.Value.(type) {
+	case int:
+		return v, true
+	case int64:
+		return int(v), true
+	case json.Number:
+		if n, err := strconv.ParseInt(string(v), 10, 0); err == nil {
+			return int(n), true
+		}
+		f, err := v.Float64()
+		if err != nil || f != math.Trunc(f) || f >= math.MaxInt64 || f < math.MinInt64 {
+			return 0, false
+		}
+		return int(f), true
+	case float64:
+		if v != math.Trunc(v) || v >= math.MaxInt64 || v < math.MinInt64 {
+			return 0, false
+		}
+		return int(v), true
+	}
+	return 0, false
+}
+
+// BoolValue returns Value as a bool.
+func (c *EntitlementCheck) BoolValue() (bool, bool) {
+	v, ok := c.Value.(bool)
+	return v, ok
+}
+
+// StringValue returns Value as a string.
+func (c *EntitlementCheck) StringValue() (string, bool) {
+	v, ok := c.Value.(string)
+	return v, ok
 }
 
 // CheckEntitlementParams are the parameters for checking an entitlement.
@@ -482,9 +2023,10 @@ type CheckEntitlementParams struct {
 }
 
 // CheckEntitlement checks if a customer has access to a feature.
-func (s *BillingService) CheckEntitlement(ctx context.Context, params *CheckEntitlementParams) (*EntitlementCheck, error) {
+func (s *BillingService) CheckEntitlement(ctx context.Context, params *CheckEntitlementParams, opts ...RequestOption) (*EntitlementCheck, error) {
 	var result EntitlementCheck
-	err := s.client.request(ctx, "POST", "/billing/v1/entitlements/check", params, &result)
+	ctx, path := s.op(ctx, "CheckEntitlement", "/billing/v1/entitlements/check")
+	err := s.client.request(ctx, "POST", path, params, &result, append([]RequestOption{readOnly}, opts...)...)
 	if err != nil {
 		return nil, err
 	}
@@ -492,11 +2034,154 @@ func (s *BillingService) CheckEntitlement(ctx context.Context, params *CheckEnti
 }
 
 // CheckEntitlementByKey is a convenience method that checks an entitlement by customer ID and key.
-func (s *BillingService) CheckEntitlementByKey(ctx context.Context, customerID, entitlementKey string) (*EntitlementCheck, error) {
+func (s *BillingService) CheckEntitlementByKey(ctx context.Context, customerID, entitlementKey string, opts ...RequestOption) (*EntitlementCheck, error) {
 	return s.CheckEntitlement(ctx, &CheckEntitlementParams{
 		CustomerID:     customerID,
 		EntitlementKey: entitlementKey,
-	})
+	}, opts...)
+}
+
+// CheckSubscriptionEntitlement checks an entitlement for the customer and
+// plan of a subscription, resolved by the API, for callers that have a
+// subscription ID but not the customer ID. Unknown subscriptions return an
+// error matching ErrNotFound.
+func (s *BillingService) CheckSubscriptionEntitlement(ctx context.Context, subscriptionID, entitlementKey string, opts ...RequestOption) (*EntitlementCheck, error) {
+	var result EntitlementCheck
+	ctx, path, err := s.route(ctx, "CheckSubscriptionEntitlement", "/billing/v1/subscriptions/{subscriptionID}/entitlements/{entitlementKey}", subscriptionID, entitlementKey)
+	if err != nil {
+		return nil, err
+	}
+	err = s.client.request(ctx, "GET", path, nil, &result, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// entitlementCheckConcurrency is the default number of parallel requests
+// made by CheckEntitlementForCustomers.
+const entitlementCheckConcurrency = 8
+
+// BatchResult reports the items of a batch operation that were not
+// processed because the context's deadline was reached.
+type BatchResult struct {
+	// Skipped lists the IDs of the items that were not processed, in input
+	// order: those not started because too little time remained to finish
+	// them, and those cut off by the deadline.
+	Skipped []string
+}
+
+// Complete reports whether every item was processed.
+func (r *BatchResult) Complete() bool {
+	return len(r.Skipped) == 0
+}
+
+// EntitlementChecks is the result of CheckEntitlementForCustomers.
+type EntitlementChecks struct {
+	BatchResult
+
+	// Checks holds the result for each customer that was checked, keyed by
+	// customer ID.
+	Checks map[string]EntitlementCheck
+}
+
+// CheckEntitlementForCustomers checks one entitlement for many customers.
+// Checks run concurrently, at most eight at a time unless set with
+// WithConcurrency, and the first failure cancels the rest and is returned.
+// When the last response reported no requests left in the rate limit window
+// (see LastRateLimit), new checks wait for the window to reset, for at most
+// the client's WithMaxRetryAfter cap. Rate-limited checks are retried
+// according to the client's retry settings (see WithMaxRetries), and only a
+// check that still fails then cancels the rest.
+//
+// When ctx has a deadline the checks share it: a check is not started once
+// the time left is shorter than the checks so far took on average, and
+// checks the deadline cuts off are not treated as failures. Such customers
+// are reported in Skipped, and the call returns the checks that completed
+// with a nil error.
+func (s *BillingService) CheckEntitlementForCustomers(ctx context.Context, customerIDs []string, entitlementKey string, opts ...RequestOption) (*EntitlementChecks, error) {
+	concurrency := newRequestOptions(opts).concurrency
+	if concurrency <= 0 {
+		concurrency = entitlementCheckConcurrency
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	deadline, hasDeadline := ctx.Deadline()
+
+	var (
+		mu       sync.Mutex
+		wg       sync.WaitGroup
+		firstErr error
+		elapsed  time.Duration // total duration of completed checks
+		done     int
+		results  = make(map[string]EntitlementCheck, len(customerIDs))
+		sem      = make(chan struct{}, concurrency)
+	)
+	// estimate returns the average duration of the checks completed so far.
+	estimate := func() time.Duration {
+		mu.Lock()
+		defer mu.Unlock()
+		if done == 0 {
+			return 0
+		}
+		return elapsed / time.Duration(done)
+	}
+
+	for _, customerID := range customerIDs {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			break
+		}
+		if wait := s.client.rateLimitWait(); wait > 0 && !sleepContext(ctx, wait) {
+			break
+		}
+		if hasDeadline && time.Until(deadline) < estimate() {
+			break
+		}
+
+		wg.Add(1)
+		go func(customerID string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			start := time.Now()
+			check, err := s.CheckEntitlementByKey(ctx, customerID, entitlementKey, opts...)
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				if errors.Is(err, context.DeadlineExceeded) && ctx.Err() != nil {
+					return // cut off by the batch deadline: reported as skipped
+				}
+				if firstErr == nil {
+					firstErr = fmt.Errorf("check entitlement for customer %s: %w", customerID, err)
+					cancel()
+				}
+				return
+			}
+			results[customerID] = *check
+			elapsed += time.Since(start)
+			done++
+		}(customerID)
+	}
+	wg.Wait()
+
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	if err := ctx.Err(); err != nil && !errors.Is(err, context.DeadlineExceeded) {
+		return nil, err
+	}
+	checks := &EntitlementChecks{Checks: results}
+	for _, customerID := range customerIDs {
+		if _, ok := results[customerID]; !ok {
+			checks.Skipped = append(checks.Skipped, customerID)
+		}
+	}
+	return checks, nil
 }
 
 // ============================================================
@@ -505,29 +2190,48 @@ func (s *BillingService) CheckEntitlementByKey(ctx context.Context, customerID,
 
 // UsageRecord represents a recorded usage event.
 type UsageRecord struct {
-	ID             string    `json:"id"`
-	CustomerID     string    `json:"customer_id,omitempty"`
-	SubscriptionID string    `json:"subscription_id,omitempty"`
-	ProductKey     string    `json:"product_key"`
-	Quantity       int       `json:"quantity"`
-	Timestamp      time.Time `json:"timestamp"`
-	IdempotencyKey string    `json:"idempotency_key,omitempty"`
-	CreatedAt      time.Time `json:"created_at"`
+	ID             string            `json:"id"`
+	CustomerID     string            `json:"customer_id,omitempty"`
+	SubscriptionID string            `json:"subscription_id,omitempty"`
+	ProductKey     string            `json:"product_key"`
+	Quantity       int               `json:"quantity"`
+	Timestamp      Time              `json:"timestamp"`
+	IdempotencyKey string            `json:"idempotency_key,omitempty"`
+	Metadata       map[string]string `json:"metadata,omitempty"`
+	CreatedAt      Time              `json:"created_at"`
 }
 
 // RecordUsageParams are the parameters for recording usage.
+//
+// Metadata is copied to the invoice line items the usage is billed on, e.g.
+// to break usage down by the feature that generated it. A nil map is
+// omitted from the request.
 type RecordUsageParams struct {
-	SubscriptionID string     `json:"subscription_id"`
-	ProductKey     string     `json:"product_key,omitempty"`
-	Quantity       int        `json:"quantity"`
-	Timestamp      *time.Time `json:"timestamp,omitempty"`
-	IdempotencyKey string     `json:"idempotency_key,omitempty"`
+	SubscriptionID string            `json:"subscription_id"`
+	ProductKey     string            `json:"product_key,omitempty"`
+	Quantity       int               `json:"quantity"`
+	Timestamp      *time.Time        `json:"timestamp,omitempty"`
+	IdempotencyKey string            `json:"idempotency_key,omitempty"`
+	Metadata       map[string]string `json:"metadata,omitempty"`
 }
 
 // RecordUsage records usage for a metered subscription.
-func (s *BillingService) RecordUsage(ctx context.Context, params *RecordUsageParams) (*UsageRecord, error) {
+// A non-empty params.IdempotencyKey is also sent as the Idempotency-Key header,
+// overriding any key set with Idempotent or WithIdempotencyKey.
+// Recording usage for a subscription paused with
+// PauseSubscriptionParams.PauseUsage fails with an error matched by
+// IsSubscriptionPaused.
+func (s *BillingService) RecordUsage(ctx context.Context, params *RecordUsageParams, opts ...RequestOption) (*UsageRecord, error) {
+	if params == nil {
+		return nil, fmt.Errorf("%w: params are required", ErrValidation)
+	}
+	if params.IdempotencyKey != "" {
+		opts = append(opts, Idempotent(params.IdempotencyKey))
+	}
+
 	var record UsageRecord
-	err := s.client.request(ctx, "POST", "/billing/v1/usage", params, &record)
+	ctx, path := s.op(ctx, "RecordUsage", "/billing/v1/usage")
+	err := s.client.request(ctx, "POST", path, params, &record, opts...)
 	if err != nil {
 		return nil, err
 	}
@@ -535,13 +2239,13 @@ func (s *BillingService) RecordUsage(ctx context.Context, params *RecordUsagePar
 }
 
 // RecordUsageByKey is a convenience method for recording usage with individual parameters.
-func (s *BillingService) RecordUsageByKey(ctx context.Context, subscriptionID, productKey string, quantity int, idempotencyKey string) (*UsageRecord, error) {
+func (s *BillingService) RecordUsageByKey(ctx context.Context, subscriptionID, productKey string, quantity int, idempotencyKey string, opts ...RequestOption) (*UsageRecord, error) {
 	return s.RecordUsage(ctx, &RecordUsageParams{
 		SubscriptionID: subscriptionID,
 		ProductKey:     productKey,
 		Quantity:       quantity,
 		IdempotencyKey: idempotencyKey,
-	})
+	}, opts...)
 }
 
 // UsageSummary is an aggregated usage summary.
@@ -561,14 +2265,14 @@ type GetUsageSummaryParams struct {
 }
 
 // GetUsageSummary gets aggregated usage for a subscription.
-func (s *BillingService) GetUsageSummary(ctx context.Context, params *GetUsageSummaryParams) (*UsageSummary, error) {
-	path := "/billing/v1/usage?subscription_id=" + params.SubscriptionID
-	if params.ProductKey != "" {
-		path += "&product_key=" + params.ProductKey
-	}
+func (s *BillingService) GetUsageSummary(ctx context.Context, params *GetUsageSummaryParams, opts ...RequestOption) (*UsageSummary, error) {
+	query := queryParams{}
+	query.set("subscription_id", params.SubscriptionID)
+	query.set("product_key", params.ProductKey)
+	ctx, path := s.op(ctx, "GetUsageSummary", "/billing/v1/usage")
 
 	var summary UsageSummary
-	err := s.client.request(ctx, "GET", path, nil, &summary)
+	err := s.client.request(ctx, "GET", query.path(path), nil, &summary, opts...)
 	if err != nil {
 		return nil, err
 	}
@@ -576,11 +2280,84 @@ func (s *BillingService) GetUsageSummary(ctx context.Context, params *GetUsageSu
 }
 
 // GetUsageSummaryByKey is a convenience method for getting usage with individual parameters.
-func (s *BillingService) GetUsageSummaryByKey(ctx context.Context, subscriptionID, productKey string) (*UsageSummary, error) {
+func (s *BillingService) GetUsageSummaryByKey(ctx context.Context, subscriptionID, productKey string, opts ...RequestOption) (*UsageSummary, error) {
 	return s.GetUsageSummary(ctx, &GetUsageSummaryParams{
 		SubscriptionID: subscriptionID,
 		ProductKey:     productKey,
-	})
+	}, opts...)
+}
+
+// ListUsageIdempotencyKeys lists the idempotency keys of the usage records
+// the server holds for a subscription with timestamps in [start, end),
+// fetching every page. A zero start or end leaves that side of the window
+// open. Diff the result against locally sent keys to find usage that never
+// landed.
+func (s *BillingService) ListUsageIdempotencyKeys(ctx context.Context, subscriptionID string, start, end time.Time, opts ...RequestOption) ([]string, error) {
+	if strings.TrimSpace(subscriptionID) == "" {
+		return nil, fmt.Errorf("%w: subscriptionID is required", ErrValidation)
+	}
+	query := queryParams{}
+	query.set("subscription_id", subscriptionID)
+	if !start.IsZero() {
+		query.set("start", start.Format(time.RFC3339Nano))
+	}
+	if !end.IsZero() {
+		query.set("end", end.Format(time.RFC3339Nano))
+	}
+
+	ctx, path := s.op(ctx, "ListUsageIdempotencyKeys", "/billing/v1/usage/idempotency-keys")
+
+	var keys []string
+	cursors := newCursorGuard("")
+	for {
+		var page struct {
+			IdempotencyKeys []string `json:"idempotency_keys"`
+			NextCursor      string   `json:"next_cursor,omitempty"`
+		}
+		err := s.client.request(ctx, "GET", query.path(path), nil, &page, opts...)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, page.IdempotencyKeys...)
+
+		if err := cursors.next(page.NextCursor); err != nil {
+			return nil, err
+		}
+		if page.NextCursor == "" {
+			return keys, nil
+		}
+		query.set("cursor", page.NextCursor)
+	}
+}
+
+// UsageMeter is current-period usage of one metered entitlement against the
+// limit included in the customer's plan.
+type UsageMeter struct {
+	EntitlementKey string `json:"entitlement_key"`
+	Limit          int    `json:"limit"`
+	Used           int    `json:"used"`
+	Remaining      int    `json:"remaining"` // zero once the limit is exceeded
+	OverageCost    Money  `json:"overage_cost"`
+	PeriodStart    string `json:"period_start"`
+	PeriodEnd      string `json:"period_end"`
+}
+
+// GetCustomerUsageMeters gets usage against limits for every metered
+// entitlement on the customer's active subscription, combining the usage
+// summary and the plan's entitlements in one call.
+func (s *BillingService) GetCustomerUsageMeters(ctx context.Context, customerID string, opts ...RequestOption) ([]UsageMeter, error) {
+	var resp struct {
+		Meters []UsageMeter `json:"meters"`
+	}
+	ctx, path, err := s.route(ctx, "GetCustomerUsageMeters", "/billing/v1/customers/{customerID}/usage-meters", customerID)
+	if err != nil {
+		return nil, err
+	}
+	err = s.client.request(ctx, "GET", path, nil, &resp, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Meters, nil
 }
 
 // ============================================================
@@ -597,12 +2374,26 @@ type PortalLink struct {
 // CreatePortalLinkParams are the parameters for creating a portal link.
 type CreatePortalLinkParams struct {
 	ExpiresInHours int `json:"expires_in_hours,omitempty"`
+
+	// State is opaque data echoed back in the redirect when the customer
+	// leaves the portal. At most MaxRedirectStateLength bytes.
+	State string `json:"state,omitempty"`
 }
 
 // CreatePortalLink creates a portal link for a customer.
-func (s *BillingService) CreatePortalLink(ctx context.Context, customerID string, params *CreatePortalLinkParams) (*PortalLink, error) {
+func (s *BillingService) CreatePortalLink(ctx context.Context, customerID string, params *CreatePortalLinkParams, opts ...RequestOption) (*PortalLink, error) {
+	if params != nil {
+		if err := validateRedirectState(params.State); err != nil {
+			return nil, err
+		}
+	}
+
 	var link PortalLink
-	err := s.client.request(ctx, "POST", "/billing/v1/customers/"+customerID+"/portal-link", params, &link)
+	ctx, path, err := s.route(ctx, "CreatePortalLink", "/billing/v1/customers/{customerID}/portal-link", customerID)
+	if err != nil {
+		return nil, err
+	}
+	err = s.client.request(ctx, "POST", path, params, &link, opts...)
 	if err != nil {
 		return nil, err
 	}
@@ -625,6 +2416,16 @@ type PaymentConfig struct {
 	UpdatedAt    time.Time      `json:"updated_at,omitempty"`
 }
 
+// UnmarshalJSON implements json.Unmarshaler. Numbers in Settings decode as
+// json.Number rather than float64, so integers above 2^53 keep their exact
+// value.
+func (c *PaymentConfig) UnmarshalJSON(data []byte) error {
+	type config PaymentConfig
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	return dec.Decode((*config)(c))
+}
+
 // CreatePaymentConfigParams are the parameters for creating a payment config.
 type CreatePaymentConfigParams struct {
 	Provider     string         `json:"provider"`
@@ -634,9 +2435,10 @@ type CreatePaymentConfigParams struct {
 }
 
 // CreatePaymentConfig creates a new payment configuration.
-func (s *BillingService) CreatePaymentConfig(ctx context.Context, params *CreatePaymentConfigParams) (*PaymentConfig, error) {
+func (s *BillingService) CreatePaymentConfig(ctx context.Context, params *CreatePaymentConfigParams, opts ...RequestOption) (*PaymentConfig, error) {
 	var config PaymentConfig
-	err := s.client.request(ctx, "POST", "/billing/v1/payment-configs", params, &config)
+	ctx, path := s.op(ctx, "CreatePaymentConfig", "/billing/v1/payment-configs")
+	err := s.client.request(ctx, "POST", path, params, &config, opts...)
 	if err != nil {
 		return nil, err
 	}
@@ -650,9 +2452,10 @@ type PaymentConfigList struct {
 }
 
 // ListPaymentConfigs lists all payment configurations for the workspace.
-func (s *BillingService) ListPaymentConfigs(ctx context.Context) (*PaymentConfigList, error) {
+func (s *BillingService) ListPaymentConfigs(ctx context.Context, opts ...RequestOption) (*PaymentConfigList, error) {
 	var list PaymentConfigList
-	err := s.client.request(ctx, "GET", "/billing/v1/payment-configs", nil, &list)
+	ctx, path := s.op(ctx, "ListPaymentConfigs", "/billing/v1/payment-configs")
+	err := s.client.request(ctx, "GET", path, nil, &list, opts...)
 	if err != nil {
 		return nil, err
 	}
@@ -660,9 +2463,13 @@ func (s *BillingService) ListPaymentConfigs(ctx context.Context) (*PaymentConfig
 }
 
 // GetPaymentConfig retrieves a payment config by ID.
-func (s *BillingService) GetPaymentConfig(ctx context.Context, id string) (*PaymentConfig, error) {
+func (s *BillingService) GetPaymentConfig(ctx context.Context, id string, opts ...RequestOption) (*PaymentConfig, error) {
 	var config PaymentConfig
-	err := s.client.request(ctx, "GET", "/billing/v1/payment-configs/"+id, nil, &config)
+	ctx, path, err := s.route(ctx, "GetPaymentConfig", "/billing/v1/payment-configs/{paymentConfigID}", id)
+	if err != nil {
+		return nil, err
+	}
+	err = s.client.request(ctx, "GET", path, nil, &config, opts...)
 	if err != nil {
 		return nil, err
 	}
@@ -678,9 +2485,13 @@ type UpdatePaymentConfigParams struct {
 }
 
 // UpdatePaymentConfig updates a payment configuration.
-func (s *BillingService) UpdatePaymentConfig(ctx context.Context, id string, params *UpdatePaymentConfigParams) (*PaymentConfig, error) {
+func (s *BillingService) UpdatePaymentConfig(ctx context.Context, id string, params *UpdatePaymentConfigParams, opts ...RequestOption) (*PaymentConfig, error) {
 	var config PaymentConfig
-	err := s.client.request(ctx, "PATCH", "/billing/v1/payment-configs/"+id, params, &config)
+	ctx, path, err := s.route(ctx, "UpdatePaymentConfig", "/billing/v1/payment-configs/{paymentConfigID}", id)
+	if err != nil {
+		return nil, err
+	}
+	err = s.client.request(ctx, "PATCH", path, params, &config, opts...)
 	if err != nil {
 		return nil, err
 	}
@@ -688,6 +2499,10 @@ func (s *BillingService) UpdatePaymentConfig(ctx context.Context, id string, par
 }
 
 // DeletePaymentConfig deletes a payment configuration.
-func (s *BillingService) DeletePaymentConfig(ctx context.Context, id string) error {
-	return s.client.request(ctx, "DELETE", "/billing/v1/payment-configs/"+id, nil, nil)
+func (s *BillingService) DeletePaymentConfig(ctx context.Context, id string, opts ...RequestOption) error {
+	ctx, path, err := s.route(ctx, "DeletePaymentConfig", "/billing/v1/payment-configs/{paymentConfigID}", id)
+	if err != nil {
+		return err
+	}
+	return s.client.request(ctx, "DELETE", path, nil, nil, opts...)
 }
@@ -0,0 +1,101 @@
+package tedo
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+)
+
+// Billing is the set of billing operations offered by BillingService. Depend
+// on it instead of the concrete type to substitute a mock in tests; get the
+// client's implementation from Client.BillingAPI.
+//
+// The interface lists every exported BillingService method except
+// ListAllEntitlements, ListCustomersSeq, ListSubscriptionsSeq and
+// ListRenewalsBetween, which require Go 1.23. Add new methods here as well.
+type Billing interface {
+	CreatePlan(ctx context.Context, params *CreatePlanParams, opts ...RequestOption) (*Plan, error)
+	ListPlans(ctx context.Context, params *ListPlansParams, opts ...RequestOption) (*PlanList, error)
+	GetPlan(ctx context.Context, id string, opts ...RequestOption) (*Plan, error)
+	UpdatePlan(ctx context.Context, id string, params *UpdatePlanParams, opts ...RequestOption) (*Plan, error)
+	DeletePlan(ctx context.Context, id string, opts ...RequestOption) error
+
+	CreatePrice(ctx context.Context, planID string, params *CreatePriceParams, opts ...RequestOption) (*Price, error)
+	ListPrices(ctx context.Context, planID string, opts ...RequestOption) (*PriceList, error)
+	ListAllPrices(ctx context.Context, params *ListAllPricesParams, opts ...RequestOption) (*PriceList, error)
+	GetPrice(ctx context.Context, planID, priceID string, opts ...RequestOption) (*Price, error)
+	GetPriceByID(ctx context.Context, priceID string, opts ...RequestOption) (*Price, error)
+	GetPriceByKey(ctx context.Context, planKey, priceKey string, opts ...RequestOption) (*Price, error)
+	GetBuiltinPrices(ctx context.Context, opts ...RequestOption) (*BuiltinPrices, error)
+	ArchivePrice(ctx context.Context, planID, priceID string, opts ...RequestOption) error
+
+	CreateEntitlement(ctx context.Context, planID string, params *CreateEntitlementParams, opts ...RequestOption) (*Entitlement, error)
+	ListEntitlements(ctx context.Context, planID string, opts ...RequestOption) (*EntitlementList, error)
+	ArchiveEntitlement(ctx context.Context, planID, entitlementID string, opts ...RequestOption) error
+	ListEntitlementKeys(ctx context.Context, opts ...RequestOption) ([]string, error)
+	IsKnownEntitlementKey(ctx context.Context, key string, opts ...RequestOption) (bool, error)
+
+	CreateCustomer(ctx context.Context, params *CreateCustomerParams, opts ...RequestOption) (*Customer, error)
+	CreateCustomerForUser(ctx context.Context, userID int, email, name string, opts ...RequestOption) (string, error)
+	GetCustomerByExternalID(ctx context.Context, externalID string, opts ...RequestOption) (*Customer, error)
+	GetCustomerByEmail(ctx context.Context, email string, opts ...RequestOption) (*Customer, error)
+	GetCustomerForUser(ctx context.Context, userID int, opts ...RequestOption) (*Customer, error)
+	UpsertCustomer(ctx context.Context, params *UpsertCustomerParams, opts ...RequestOption) (*Customer, bool, error)
+	GetCustomer(ctx context.Context, id string, opts ...RequestOption) (*Customer, error)
+	GetCustomerRaw(ctx context.Context, id string, opts ...RequestOption) (*Customer, json.RawMessage, error)
+	ListCustomers(ctx context.Context, params *ListCustomersParams, opts ...RequestOption) (*CustomerList, error)
+	ListCustomersIter(ctx context.Context, params *ListCustomersParams, opts ...RequestOption) *CustomerIter
+	UpdateCustomer(ctx context.Context, id string, params *UpdateCustomerParams, opts ...RequestOption) (*Customer, error)
+	DeleteCustomer(ctx context.Context, id string, opts ...RequestOption) error
+
+	CreateSubscription(ctx context.Context, params *CreateSubscriptionParams, opts ...RequestOption) (*Subscription, error)
+	CreateSubscriptionIfNone(ctx context.Context, params *CreateSubscriptionParams, opts ...RequestOption) (*Subscription, bool, error)
+	CreateSubscriptionForWorkspace(ctx context.Context, customerID, workspaceID string, opts ...RequestOption) (string, error)
+	CreateSubscriptionForGuestWorkspace(ctx context.Context, customerID, workspaceID string, opts ...RequestOption) (string, error)
+	CreateSubscriptionForBasicPlan(ctx context.Context, customerID string, opts ...RequestOption) (string, error)
+	GetSubscription(ctx context.Context, id string, opts ...RequestOption) (*Subscription, error)
+	ListSubscriptions(ctx context.Context, params *ListSubscriptionsParams, opts ...RequestOption) (*SubscriptionList, error)
+	ListSubscriptionsForCustomer(ctx context.Context, customerID string, opts ...RequestOption) ([]Subscription, error)
+	ListSubscriptionsIter(ctx context.Context, params *ListSubscriptionsParams, opts ...RequestOption) *SubscriptionIter
+	UpdateSubscription(ctx context.Context, id string, params *UpdateSubscriptionParams, opts ...RequestOption) (*Subscription, error)
+	DowngradeToFree(ctx context.Context, subscriptionID string, opts ...RequestOption) (*Subscription, error)
+	ChangeSubscription(ctx context.Context, id string, params *ChangeSubscriptionParams, opts ...RequestOption) (*ChangeSubscriptionResult, error)
+	PauseSubscription(ctx context.Context, id string, params *PauseSubscriptionParams, opts ...RequestOption) (*Subscription, error)
+	ResumeSubscription(ctx context.Context, id string, opts ...RequestOption) (*Subscription, error)
+	PreviewSubscriptionChange(ctx context.Context, subscriptionID string, params *PreviewSubscriptionChangeParams, opts ...RequestOption) (*SubscriptionChangePreview, error)
+	CancelSubscription(ctx context.Context, id string, params *CancelSubscriptionParams, opts ...RequestOption) (*Subscription, error)
+	ReactivateSubscription(ctx context.Context, id string, opts ...RequestOption) (*Subscription, error)
+	PreviewCancellationRefund(ctx context.Context, subscriptionID string, opts ...RequestOption) (*Money, error)
+	TransferSubscription(ctx context.Context, subscriptionID, newCustomerID string, opts ...RequestOption) (*Subscription, error)
+	GetSubscriptionStats(ctx context.Context, params *GetSubscriptionStatsParams, opts ...RequestOption) (*SubscriptionStats, error)
+	ListSubscriptionQuantityChanges(ctx context.Context, subscriptionID string, opts ...RequestOption) ([]QuantityChange, error)
+
+	CreateCheckoutLink(ctx context.Context, subscriptionID string, params *CreateCheckoutLinkParams, opts ...RequestOption) (*CheckoutLink, error)
+
+	CheckEntitlement(ctx context.Context, params *CheckEntitlementParams, opts ...RequestOption) (*EntitlementCheck, error)
+	CheckEntitlementByKey(ctx context.Context, customerID, entitlementKey string, opts ...RequestOption) (*EntitlementCheck, error)
+	CheckSubscriptionEntitlement(ctx context.Context, subscriptionID, entitlementKey string, opts ...RequestOption) (*EntitlementCheck, error)
+	CheckEntitlementForCustomers(ctx context.Context, customerIDs []string, entitlementKey string, opts ...RequestOption) (*EntitlementChecks, error)
+
+	RecordUsage(ctx context.Context, params *RecordUsageParams, opts ...RequestOption) (*UsageRecord, error)
+	RecordUsageByKey(ctx context.Context, subscriptionID, productKey string, quantity int, idempotencyKey string, opts ...RequestOption) (*UsageRecord, error)
+	GetUsageSummary(ctx context.Context, params *GetUsageSummaryParams, opts ...RequestOption) (*UsageSummary, error)
+	GetUsageSummaryByKey(ctx context.Context, subscriptionID, productKey string, opts ...RequestOption) (*UsageSummary, error)
+	ListUsageIdempotencyKeys(ctx context.Context, subscriptionID string, start, end time.Time, opts ...RequestOption) ([]string, error)
+	GetCustomerUsageMeters(ctx context.Context, customerID string, opts ...RequestOption) ([]UsageMeter, error)
+
+	CreatePortalLink(ctx context.Context, customerID string, params *CreatePortalLinkParams, opts ...RequestOption) (*PortalLink, error)
+
+	CreatePaymentConfig(ctx context.Context, params *CreatePaymentConfigParams, opts ...RequestOption) (*PaymentConfig, error)
+	ListPaymentConfigs(ctx context.Context, opts ...RequestOption) (*PaymentConfigList, error)
+	GetPaymentConfig(ctx context.Context, id string, opts ...RequestOption) (*PaymentConfig, error)
+	UpdatePaymentConfig(ctx context.Context, id string, params *UpdatePaymentConfigParams, opts ...RequestOption) (*PaymentConfig, error)
+	DeletePaymentConfig(ctx context.Context, id string, opts ...RequestOption) error
+}
+
+var _ Billing = (*BillingService)(nil)
+
+// BillingAPI returns the client's billing service as a Billing.
+func (c *Client) BillingAPI() Billing {
+	return c.Billing
+}
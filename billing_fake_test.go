@@ -0,0 +1,53 @@
+package tedo_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/tedo-ai/tedo-go"
+	"github.com/tedo-ai/tedo-go/tedotest"
+)
+
+func TestListAllPrices(t *testing.T) {
+	srv := tedotest.NewServer(t)
+	srv.SeedPlan(tedo.Plan{Key: "free", Name: "Free"})
+	srv.SeedPlan(tedo.Plan{
+		Key:  "pro",
+		Name: "Pro",
+		Prices: []tedo.Price{
+			{Key: "monthly", Amount: 2900, Currency: "eur"},
+			{Key: "monthly_usd", Amount: 3200, Currency: "usd"},
+		},
+	})
+	billing := srv.Client().Billing
+	ctx := context.Background()
+
+	list, err := billing.ListAllPrices(ctx, nil)
+	if err != nil {
+		t.Fatalf("ListAllPrices: %v", err)
+	}
+	if len(list.Prices) != 2 {
+		t.Fatalf("got %d prices, want 2: the plan without prices contributes none", len(list.Prices))
+	}
+	for _, p := range list.Prices {
+		if p.PlanKey != "pro" {
+			t.Errorf("price %s has PlanKey %q, want pro", p.Key, p.PlanKey)
+		}
+	}
+
+	list, err = billing.ListAllPrices(ctx, &tedo.ListAllPricesParams{Currency: "usd"})
+	if err != nil {
+		t.Fatalf("ListAllPrices(usd): %v", err)
+	}
+	if len(list.Prices) != 1 || list.Prices[0].Key != "monthly_usd" {
+		t.Errorf("usd prices = %+v, want monthly_usd only", list.Prices)
+	}
+
+	list, err = billing.ListAllPrices(ctx, &tedo.ListAllPricesParams{Currency: "gbp"})
+	if err != nil {
+		t.Fatalf("ListAllPrices(gbp): %v", err)
+	}
+	if len(list.Prices) != 0 || list.NextCursor != "" {
+		t.Errorf("gbp prices = %+v, want none", list)
+	}
+}
@@ -0,0 +1,133 @@
+//go:build go1.23
+
+// The methods in this file return iter.Seq2 and so are only built with Go
+// 1.23 or later, while the module supports Go 1.21. They are not part of the
+// Billing interface.
+
+package tedo
+
+import (
+	"context"
+	"fmt"
+	"iter"
+	"time"
+)
+
+// ListAllEntitlements iterates over the entitlements of every plan, with
+// PlanKey set to the owning plan's key. Plans are fetched a page at a time
+// and each plan's entitlements just before they are yielded, so stopping
+// early saves the remaining requests. Iteration stops at the first error,
+// including cancellation of ctx between plans; with WithStopOnError(false)
+// a plan whose entitlements fail to load is yielded as an error and
+// skipped. It requires Go 1.23.
+func (s *BillingService) ListAllEntitlements(ctx context.Context, opts ...RequestOption) iter.Seq2[*Entitlement, error] {
+	return func(yield func(*Entitlement, error) bool) {
+		keepGoing := newRequestOptions(opts).keepGoing
+		var params ListPlansParams
+		cursors := newCursorGuard("")
+		for {
+			plans, err := s.ListPlans(ctx, &params, opts...)
+			if err == nil {
+				err = cursors.next(plans.NextCursor)
+			}
+			if err != nil {
+				yield(nil, err)
+				return
+			}
+
+			for _, plan := range plans.Plans {
+				if err := ctx.Err(); err != nil {
+					yield(nil, err)
+					return
+				}
+
+				list, err := s.ListEntitlements(ctx, plan.ID, opts...)
+				if err != nil {
+					if !yield(nil, fmt.Errorf("list entitlements of plan %q: %w", plan.Key, err)) || !keepGoing {
+						return
+					}
+					continue
+				}
+				for i := range list.Entitlements {
+					entitlement := &list.Entitlements[i]
+					entitlement.PlanKey = plan.Key
+					if !yield(entitlement, nil) {
+						return
+					}
+				}
+			}
+
+			if plans.NextCursor == "" {
+				return
+			}
+			params.Cursor = plans.NextCursor
+		}
+	}
+}
+
+// ListCustomersSeq iterates over all customers, fetching pages as needed; see
+// ListCustomersIter. Iteration stops at the first error, including
+// cancellation of ctx, whatever WithStopOnError says. It requires Go 1.23.
+func (s *BillingService) ListCustomersSeq(ctx context.Context, params *ListCustomersParams, opts ...RequestOption) iter.Seq2[*Customer, error] {
+	return func(yield func(*Customer, error) bool) {
+		it := s.ListCustomersIter(ctx, params, opts...)
+		for it.Next() {
+			if !yield(it.Customer(), nil) {
+				return
+			}
+		}
+		if err := it.Err(); err != nil {
+			yield(nil, err)
+		}
+	}
+}
+
+// ListSubscriptionsSeq iterates over all subscriptions matching params,
+// fetching pages as needed; see ListSubscriptionsIter. Iteration stops at
+// the first error, including cancellation of ctx, whatever WithStopOnError
+// says. It requires Go 1.23.
+func (s *BillingService) ListSubscriptionsSeq(ctx context.Context, params *ListSubscriptionsParams, opts ...RequestOption) iter.Seq2[*Subscription, error] {
+	return func(yield func(*Subscription, error) bool) {
+		it := s.ListSubscriptionsIter(ctx, params, opts...)
+		for it.Next() {
+			if !yield(it.Subscription(), nil) {
+				return
+			}
+		}
+		if err := it.Err(); err != nil {
+			yield(nil, err)
+		}
+	}
+}
+
+// ListRenewalsBetween iterates over the subscriptions that renew in
+// [start, end): those whose CurrentPeriodEnd falls in the window, filtered
+// by the server a page at a time. Canceled subscriptions and those set to
+// cancel at period end are skipped, since they won't renew. Iteration stops
+// at the first error, whatever WithStopOnError says. It requires Go 1.23.
+//
+// start and end are instants, so their time zones don't matter: a window
+// from midnight in Europe/Amsterdam covers the same subscriptions as the
+// equivalent UTC time. To select renewals by a customer's calendar day,
+// build the bounds with time.Date in the customer's location.
+func (s *BillingService) ListRenewalsBetween(ctx context.Context, start, end time.Time, opts ...RequestOption) iter.Seq2[*Subscription, error] {
+	return func(yield func(*Subscription, error) bool) {
+		if !start.Before(end) {
+			yield(nil, fmt.Errorf("%w: renewal window start %s is not before end %s", ErrValidation, start, end))
+			return
+		}
+		params := &ListSubscriptionsParams{PeriodEndAfter: start, PeriodEndBefore: end}
+		for sub, err := range s.ListSubscriptionsSeq(ctx, params, opts...) {
+			if err != nil {
+				yield(nil, err)
+				return
+			}
+			if sub.Status == string(SubscriptionStatusCanceled) || sub.CancelAtPeriodEnd {
+				continue
+			}
+			if !yield(sub, nil) {
+				return
+			}
+		}
+	}
+}
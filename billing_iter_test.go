@@ -0,0 +1,213 @@
+//go:build go1.23
+
+package tedo
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestListAllEntitlementsFetchesPerPlan(t *testing.T) {
+	var requests []string
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.URL.Path)
+		switch r.URL.Path {
+		case "/billing/v1/plans":
+			w.Write([]byte(`{"plans":[{"id":"plan_1","key":"pro"},{"id":"plan_2","key":"team"}]}`))
+		case "/billing/v1/plans/plan_1/entitlements":
+			w.Write([]byte(`{"entitlements":[{"id":"ent_1","key":"seats"},{"id":"ent_2","key":"exports"}]}`))
+		default:
+			t.Errorf("unexpected request %s", r.URL.Path)
+		}
+	})
+
+	for entitlement, err := range c.Billing.ListAllEntitlements(context.Background()) {
+		if err != nil {
+			t.Fatalf("ListAllEntitlements: %v", err)
+		}
+		if entitlement.PlanKey != "pro" {
+			t.Errorf("PlanKey = %q, want pro", entitlement.PlanKey)
+		}
+		break
+	}
+	if len(requests) != 2 {
+		t.Errorf("requests = %q, want the plans page and one plan's entitlements", requests)
+	}
+}
+
+func TestListCustomersSeqPages(t *testing.T) {
+	pages := map[string]string{
+		"":   `{"customers":[{"id":"cus_1"},{"id":"cus_2"}],"next_cursor":"c2"}`,
+		"c2": `{"customers":[{"id":"cus_3"}],"next_cursor":"c3"}`,
+		"c3": `{"customers":[{"id":"cus_4"}]}`,
+	}
+	var calls int
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Write([]byte(pages[r.URL.Query().Get("cursor")]))
+	})
+
+	var ids []string
+	for customer, err := range c.Billing.ListCustomersSeq(context.Background(), nil) {
+		if err != nil {
+			t.Fatalf("ListCustomersSeq: %v", err)
+		}
+		ids = append(ids, customer.ID)
+	}
+	if len(ids) != 4 || ids[0] != "cus_1" || ids[3] != "cus_4" {
+		t.Errorf("ids = %q, want cus_1 to cus_4", ids)
+	}
+	if calls != 3 {
+		t.Errorf("made %d requests, want 3", calls)
+	}
+}
+
+func TestListCustomersSeqRepeatedCursor(t *testing.T) {
+	var calls int
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Write([]byte(`{"customers":[{"id":"cus_1"}],"next_cursor":"same"}`))
+	})
+
+	var err error
+	for _, err = range c.Billing.ListCustomersSeq(context.Background(), nil) {
+		if err != nil {
+			break
+		}
+	}
+	if !errors.Is(err, ErrPaginationLoop) {
+		t.Errorf("error = %v, want ErrPaginationLoop", err)
+	}
+	if calls != 2 {
+		t.Errorf("made %d requests, want 2", calls)
+	}
+}
+
+func TestListSubscriptionsSeq(t *testing.T) {
+	var queries []url.Values
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		queries = append(queries, query)
+		if query.Get("cursor") == "" {
+			w.Write([]byte(`{"subscriptions":[{"id":"sub_1"}],"next_cursor":"a+b/c"}`))
+			return
+		}
+		w.Write([]byte(`{"subscriptions":[{"id":"sub_2"}]}`))
+	})
+
+	params := &ListSubscriptionsParams{
+		CustomerID: "cus_1",
+		Status:     SubscriptionStatusPastDue,
+		PlanKey:    "pro & team",
+		Limit:      1,
+	}
+	var ids []string
+	for sub, err := range c.Billing.ListSubscriptionsSeq(context.Background(), params) {
+		if err != nil {
+			t.Fatalf("ListSubscriptionsSeq: %v", err)
+		}
+		ids = append(ids, sub.ID)
+	}
+	if len(ids) != 2 || ids[0] != "sub_1" || ids[1] != "sub_2" {
+		t.Errorf("ids = %q, want sub_1, sub_2", ids)
+	}
+	if len(queries) != 2 {
+		t.Fatalf("made %d requests, want 2", len(queries))
+	}
+	for _, query := range queries {
+		if query.Get("customer_id") != "cus_1" || query.Get("status") != "past_due" ||
+			query.Get("plan_key") != "pro & team" || query.Get("limit") != "1" {
+			t.Errorf("query = %v, want every filter on every page", query)
+		}
+	}
+	if got := queries[1].Get("cursor"); got != "a+b/c" {
+		t.Errorf("second page cursor = %q, want a+b/c", got)
+	}
+}
+
+func TestListRenewalsBetween(t *testing.T) {
+	var query url.Values
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		query = r.URL.Query()
+		w.Write([]byte(`{"subscriptions":[
+			{"id":"sub_1","status":"active"},
+			{"id":"sub_2","status":"active","cancel_at_period_end":true},
+			{"id":"sub_3","status":"canceled"},
+			{"id":"sub_4","status":"trialing"}
+		]}`))
+	})
+
+	amsterdam := time.FixedZone("CET", 3600)
+	start := time.Date(2026, 11, 1, 0, 0, 0, 0, amsterdam)
+	var ids []string
+	for sub, err := range c.Billing.ListRenewalsBetween(context.Background(), start, start.AddDate(0, 0, 7)) {
+		if err != nil {
+			t.Fatalf("ListRenewalsBetween: %v", err)
+		}
+		ids = append(ids, sub.ID)
+	}
+	if len(ids) != 2 || ids[0] != "sub_1" || ids[1] != "sub_4" {
+		t.Errorf("ids = %q, want sub_1, sub_4", ids)
+	}
+	if got := query.Get("current_period_end_gte"); got != "2026-10-31T23:00:00Z" {
+		t.Errorf("window start = %q, want it in UTC", got)
+	}
+	if got := query.Get("current_period_end_lt"); got != "2026-11-07T23:00:00Z" {
+		t.Errorf("window end = %q, want it in UTC", got)
+	}
+
+	for _, err := range c.Billing.ListRenewalsBetween(context.Background(), start, start) {
+		if !errors.Is(err, ErrValidation) {
+			t.Errorf("empty window error = %v, want ErrValidation", err)
+		}
+	}
+}
+
+func TestListAllEntitlementsStopOnError(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/billing/v1/plans":
+			w.Write([]byte(`{"plans":[{"id":"plan_1","key":"pro"},{"id":"plan_2","key":"team"}]}`))
+		case "/billing/v1/plans/plan_1/entitlements":
+			http.Error(w, `{"code":"not_found","message":"plan not found"}`, http.StatusNotFound)
+		default:
+			w.Write([]byte(`{"entitlements":[{"id":"ent_1","key":"seats"}]}`))
+		}
+	})
+
+	tests := []struct {
+		name    string
+		opts    []RequestOption
+		wantIDs int
+	}{
+		{"default", nil, 0},
+		{"stop", []RequestOption{WithStopOnError(true)}, 0},
+		{"continue", []RequestOption{WithStopOnError(false)}, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var errs []error
+			ids := 0
+			for entitlement, err := range c.Billing.ListAllEntitlements(context.Background(), tt.opts...) {
+				if err != nil {
+					errs = append(errs, err)
+					continue
+				}
+				if entitlement.PlanKey != "team" {
+					t.Errorf("PlanKey = %q, want team", entitlement.PlanKey)
+				}
+				ids++
+			}
+			if len(errs) != 1 || !IsNotFound(errs[0]) {
+				t.Errorf("errors = %v, want one not found", errs)
+			}
+			if ids != tt.wantIDs {
+				t.Errorf("got %d entitlements, want %d", ids, tt.wantIDs)
+			}
+		})
+	}
+}
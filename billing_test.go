@@ -0,0 +1,1121 @@
+package tedo
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestListSubscriptionQuantityChangesForwardsOptions(t *testing.T) {
+	var headers []string
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		headers = append(headers, r.Header.Get("X-Correlation-Id"))
+		if r.URL.Query().Get("cursor") == "" {
+			w.Write([]byte(`{"quantity_changes":[{"old_quantity":1,"new_quantity":2}],"next_cursor":"c2"}`))
+			return
+		}
+		w.Write([]byte(`{"quantity_changes":[{"old_quantity":2,"new_quantity":5}]}`))
+	})
+
+	changes, err := c.Billing.ListSubscriptionQuantityChanges(context.Background(), "sub_1", WithHeader("X-Correlation-Id", "abc"))
+	if err != nil {
+		t.Fatalf("ListSubscriptionQuantityChanges: %v", err)
+	}
+	if len(changes) != 2 {
+		t.Errorf("got %d changes, want 2", len(changes))
+	}
+	if len(headers) != 2 || headers[0] != "abc" || headers[1] != "abc" {
+		t.Errorf("X-Correlation-Id per page = %q, want abc on both pages", headers)
+	}
+}
+
+func TestCheckEntitlementForCustomersDeadline(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(20 * time.Millisecond)
+		w.Write([]byte(`{"has_access":true}`))
+	})
+	ids := make([]string, 64)
+	for i := range ids {
+		ids[i] = fmt.Sprintf("cus_%d", i)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 70*time.Millisecond)
+	defer cancel()
+	checks, err := c.Billing.CheckEntitlementForCustomers(ctx, ids, "seats")
+	if err != nil {
+		t.Fatalf("CheckEntitlementForCustomers: %v", err)
+	}
+	if len(checks.Checks) == 0 || checks.Complete() {
+		t.Fatalf("got %d checks and %d skipped, want partial completion", len(checks.Checks), len(checks.Skipped))
+	}
+	if len(checks.Checks)+len(checks.Skipped) != len(ids) {
+		t.Errorf("%d checks + %d skipped != %d customers", len(checks.Checks), len(checks.Skipped), len(ids))
+	}
+	for _, id := range checks.Skipped {
+		if _, ok := checks.Checks[id]; ok {
+			t.Errorf("%s both checked and skipped", id)
+		}
+	}
+	if last := checks.Skipped[len(checks.Skipped)-1]; last != "cus_63" {
+		t.Errorf("last skipped = %s, want cus_63", last)
+	}
+}
+
+func TestCheckEntitlementForCustomersNoDeadline(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"has_access":true}`))
+	})
+	checks, err := c.Billing.CheckEntitlementForCustomers(context.Background(), []string{"cus_1", "cus_2"}, "seats")
+	if err != nil {
+		t.Fatalf("CheckEntitlementForCustomers: %v", err)
+	}
+	if !checks.Complete() || len(checks.Checks) != 2 || !checks.Checks["cus_1"].HasAccess {
+		t.Errorf("checks = %+v, want both customers with access", checks)
+	}
+}
+
+func TestCheckEntitlementForCustomersConcurrency(t *testing.T) {
+	var inFlight, peak atomic.Int32
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		n := inFlight.Add(1)
+		defer inFlight.Add(-1)
+		for p := peak.Load(); n > p && !peak.CompareAndSwap(p, n); p = peak.Load() {
+		}
+		time.Sleep(5 * time.Millisecond)
+		w.Write([]byte(`{"has_access":true}`))
+	})
+	ids := []string{"cus_1", "cus_2", "cus_3", "cus_4", "cus_5", "cus_6"}
+
+	for _, concurrency := range []int{1, 2} {
+		peak.Store(0)
+		checks, err := c.Billing.CheckEntitlementForCustomers(context.Background(), ids, "seats", WithConcurrency(concurrency))
+		if err != nil {
+			t.Fatalf("CheckEntitlementForCustomers: %v", err)
+		}
+		if len(checks.Checks) != len(ids) {
+			t.Errorf("got %d checks, want %d", len(checks.Checks), len(ids))
+		}
+		if p := peak.Load(); p > int32(concurrency) {
+			t.Errorf("WithConcurrency(%d): %d requests in flight", concurrency, p)
+		}
+	}
+}
+
+func TestCheckEntitlementForCustomersThrottles(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-RateLimit-Limit", "100")
+		w.Header().Set("X-RateLimit-Remaining", "0")
+		w.Header().Set("X-RateLimit-Reset", strconv.FormatInt(time.Now().Add(time.Hour).Unix(), 10))
+		w.Write([]byte(`{"has_access":true}`))
+	}).WithMaxRetryAfter(30 * time.Millisecond)
+
+	start := time.Now()
+	checks, err := c.Billing.CheckEntitlementForCustomers(context.Background(), []string{"cus_1", "cus_2", "cus_3"}, "seats", WithConcurrency(1))
+	if err != nil {
+		t.Fatalf("CheckEntitlementForCustomers: %v", err)
+	}
+	if len(checks.Checks) != 3 {
+		t.Errorf("got %d checks, want 3", len(checks.Checks))
+	}
+	if d := time.Since(start); d < 60*time.Millisecond {
+		t.Errorf("took %v, want waits for the rate limit window between checks", d)
+	}
+}
+
+func TestTransferSubscriptionValidatesCustomer(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+	})
+	for _, id := range []string{"", "  "} {
+		if _, err := c.Billing.TransferSubscription(context.Background(), "sub_1", id); !errors.Is(err, ErrValidation) {
+			t.Errorf("TransferSubscription(%q) error = %v, want ErrValidation", id, err)
+		}
+	}
+}
+
+func TestListAllPricesFillsPlanKeys(t *testing.T) {
+	var planRequests int
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/billing/v1/prices":
+			if got := r.URL.Query().Get("currency"); got != "usd" {
+				t.Errorf("currency = %q, want usd", got)
+			}
+			w.Write([]byte(`{"prices":[{"id":"price_1","plan_id":"plan_1"},{"id":"price_2","plan_id":"plan_2","plan_key":"team"}],"total":2}`))
+		case "/billing/v1/plans":
+			planRequests++
+			w.Write([]byte(`{"plans":[{"id":"plan_1","key":"pro"},{"id":"plan_2","key":"team"}]}`))
+		default:
+			t.Errorf("unexpected request %s", r.URL.Path)
+		}
+	})
+
+	list, err := c.Billing.ListAllPrices(context.Background(), &ListAllPricesParams{Currency: "usd"})
+	if err != nil {
+		t.Fatalf("ListAllPrices: %v", err)
+	}
+	if list.Prices[0].PlanKey != "pro" || list.Prices[1].PlanKey != "team" {
+		t.Errorf("plan keys = %q, %q; want pro, team", list.Prices[0].PlanKey, list.Prices[1].PlanKey)
+	}
+	if planRequests != 1 {
+		t.Errorf("plans fetched %d times, want 1", planRequests)
+	}
+}
+
+func TestPaginationLoopDetected(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"customers":[],"plans":[],"quantity_changes":[],"idempotency_keys":[],"next_cursor":"same"}`))
+	})
+	ctx := context.Background()
+
+	tests := map[string]func() error{
+		"CustomerIter": func() error {
+			it := c.Billing.ListCustomersIter(ctx, nil)
+			for it.Next() {
+			}
+			return it.Err()
+		},
+		"SubscriptionIter": func() error {
+			it := c.Billing.ListSubscriptionsIter(ctx, nil)
+			for it.Next() {
+			}
+			return it.Err()
+		},
+		"ListEntitlementKeys": func() error {
+			_, err := c.Billing.ListEntitlementKeys(ctx)
+			return err
+		},
+		"ListSubscriptionQuantityChanges": func() error {
+			_, err := c.Billing.ListSubscriptionQuantityChanges(ctx, "sub_1")
+			return err
+		},
+		"ListUsageIdempotencyKeys": func() error {
+			_, err := c.Billing.ListUsageIdempotencyKeys(ctx, "sub_1", time.Time{}, time.Now())
+			return err
+		},
+	}
+	for name, call := range tests {
+		if err := call(); !errors.Is(err, ErrPaginationLoop) {
+			t.Errorf("%s error = %v, want ErrPaginationLoop", name, err)
+		}
+	}
+}
+
+func TestCreateSubscriptionIfNone(t *testing.T) {
+	tests := []struct {
+		name       string
+		existing   string // status of the customer's subscription, if any
+		createCode int
+		refetch    string // status after a 409
+		wantID     string
+		wantNew    bool
+		wantErr    error
+	}{
+		{name: "none", createCode: 201, wantID: "sub_new", wantNew: true},
+		{name: "active", existing: "active", wantID: "sub_old"},
+		{name: "trialing", existing: "trialing", wantID: "sub_old"},
+		{name: "canceled", existing: "canceled", createCode: 201, wantID: "sub_new", wantNew: true},
+		{name: "past due", existing: "past_due", createCode: 201, wantID: "sub_new", wantNew: true},
+		{name: "conflict with winner", createCode: 409, refetch: "active", wantID: "sub_old"},
+		{name: "conflict without winner", createCode: 409, wantErr: ErrConflict},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var created bool
+			c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+				switch r.Method {
+				case http.MethodGet:
+					status := tt.existing
+					if created {
+						status = tt.refetch
+					}
+					if status == "" {
+						w.Write([]byte(`{"id":"cus_1"}`))
+						return
+					}
+					fmt.Fprintf(w, `{"id":"cus_1","subscriptions":[{"id":"sub_old","status":%q}]}`, status)
+				case http.MethodPost:
+					created = true
+					w.WriteHeader(tt.createCode)
+					if tt.createCode == http.StatusConflict {
+						w.Write([]byte(`{"code":"conflict","message":"subscription exists"}`))
+						return
+					}
+					w.Write([]byte(`{"id":"sub_new","status":"active"}`))
+				}
+			})
+
+			sub, isNew, err := c.Billing.CreateSubscriptionIfNone(context.Background(), &CreateSubscriptionParams{CustomerID: "cus_1", PriceID: "price_1"})
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("CreateSubscriptionIfNone: %v", err)
+			}
+			if sub.ID != tt.wantID || isNew != tt.wantNew {
+				t.Errorf("got %s, new=%v; want %s, new=%v", sub.ID, isNew, tt.wantID, tt.wantNew)
+			}
+		})
+	}
+
+	c := NewClient("tedo_test_key")
+	if _, _, err := c.Billing.CreateSubscriptionIfNone(context.Background(), nil); !errors.Is(err, ErrValidation) {
+		t.Errorf("nil params error = %v, want ErrValidation", err)
+	}
+}
+
+func TestCreatePriceParamsValidate(t *testing.T) {
+	tests := []struct {
+		interval string
+		count    int
+		valid    bool
+	}{
+		{"month", 0, true},
+		{"", 1, true},
+		{"", 12, true},
+		{"", 13, false},
+		{"month", 12, true},
+		{"month", 13, false},
+		{"year", 3, true},
+		{"year", 4, false},
+		{"week", 52, true},
+		{"month", -1, false},
+	}
+	for _, tt := range tests {
+		err := (&CreatePriceParams{Key: "p", Interval: tt.interval, IntervalCount: tt.count}).Validate()
+		if tt.valid && err != nil {
+			t.Errorf("%d %s: unexpected error %v", tt.count, tt.interval, err)
+		}
+		if !tt.valid && !errors.Is(err, ErrValidation) {
+			t.Errorf("%d %s: error = %v, want ErrValidation", tt.count, tt.interval, err)
+		}
+	}
+
+	var nilParams *CreatePriceParams
+	if err := nilParams.Validate(); !errors.Is(err, ErrValidation) {
+		t.Errorf("nil params error = %v, want ErrValidation", err)
+	}
+}
+
+func TestCreatePriceLimits(t *testing.T) {
+	var calls int
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Write([]byte(`{"id":"price_1"}`))
+	})
+	ctx := context.Background()
+
+	if _, err := c.Billing.CreatePrice(ctx, "plan_1", nil); !errors.Is(err, ErrValidation) {
+		t.Errorf("nil params error = %v, want ErrValidation", err)
+	}
+	yearly := &CreatePriceParams{Key: "p", Interval: "year", IntervalCount: 5}
+	if _, err := c.Billing.CreatePrice(ctx, "plan_1", yearly); !errors.Is(err, ErrValidation) {
+		t.Errorf("5 years error = %v, want ErrValidation", err)
+	}
+	if calls != 0 {
+		t.Errorf("invalid prices sent %d requests", calls)
+	}
+
+	relaxed := c.WithMaxIntervalCount("year", 5)
+	if _, err := relaxed.Billing.CreatePrice(ctx, "plan_1", yearly); err != nil {
+		t.Errorf("5 years with raised limit: %v", err)
+	}
+	if _, err := c.Billing.CreatePrice(ctx, "plan_1", yearly); !errors.Is(err, ErrValidation) {
+		t.Errorf("raising the limit on a derived client changed the original: %v", err)
+	}
+	if err := yearly.Validate(); !errors.Is(err, ErrValidation) {
+		t.Errorf("raising a client's limit changed the default: %v", err)
+	}
+}
+
+func TestRedirectStateTooLong(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("unexpected request %s", r.URL.Path)
+	})
+	state := strings.Repeat("x", MaxRedirectStateLength+1)
+	ctx := context.Background()
+
+	if _, err := c.Billing.CreateCheckoutLink(ctx, "sub_1", &CreateCheckoutLinkParams{State: state}); !errors.Is(err, ErrValidation) {
+		t.Errorf("CreateCheckoutLink error = %v, want ErrValidation", err)
+	}
+	if _, err := c.Billing.CreatePortalLink(ctx, "cus_1", &CreatePortalLinkParams{State: state}); !errors.Is(err, ErrValidation) {
+		t.Errorf("CreatePortalLink error = %v, want ErrValidation", err)
+	}
+}
+
+func upTo(n int) *int { return &n }
+
+func TestCostFor(t *testing.T) {
+	tiers := []PriceTier{
+		{UpTo: upTo(10), UnitAmount: 100},
+		{UpTo: upTo(20), UnitAmount: 80, FlatAmount: 500},
+		{UnitAmount: 50},
+	}
+	bounded := tiers[:2]
+
+	tests := []struct {
+		name     string
+		price    Price
+		quantity int
+		want     int
+		invalid  bool
+	}{
+		{name: "per unit", price: Price{Amount: 250}, quantity: 4, want: 1000},
+		{name: "zero quantity", price: Price{Amount: 250}, quantity: 0, want: 0},
+		{name: "graduated first tier", price: tiered(TiersModeGraduated, tiers), quantity: 5, want: 500},
+		{name: "graduated on first bound", price: tiered(TiersModeGraduated, tiers), quantity: 10, want: 1000},
+		{name: "graduated just past first bound", price: tiered(TiersModeGraduated, tiers), quantity: 11, want: 1000 + 80 + 500},
+		{name: "graduated on second bound", price: tiered(TiersModeGraduated, tiers), quantity: 20, want: 1000 + 800 + 500},
+		{name: "graduated unbounded tier", price: tiered(TiersModeGraduated, tiers), quantity: 25, want: 1000 + 800 + 500 + 250},
+		{name: "graduated on last bound", price: tiered(TiersModeGraduated, bounded), quantity: 20, want: 1000 + 800 + 500},
+		{name: "graduated past last bound", price: tiered(TiersModeGraduated, bounded), quantity: 21, invalid: true},
+		{name: "volume on first bound", price: tiered(TiersModeVolume, tiers), quantity: 10, want: 1000},
+		{name: "volume just past first bound", price: tiered(TiersModeVolume, tiers), quantity: 11, want: 11*80 + 500},
+		{name: "volume unbounded tier", price: tiered(TiersModeVolume, tiers), quantity: 30, want: 1500},
+		{name: "volume on last bound", price: tiered(TiersModeVolume, bounded), quantity: 20, want: 20*80 + 500},
+		{name: "volume past last bound", price: tiered(TiersModeVolume, bounded), quantity: 21, invalid: true},
+		{name: "no tiers", price: tiered(TiersModeVolume, nil), quantity: 1, invalid: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.price.Currency = "eur"
+			cost, err := tt.price.CostFor(tt.quantity)
+			if tt.invalid {
+				if !errors.Is(err, ErrValidation) {
+					t.Errorf("CostFor(%d) error = %v, want ErrValidation", tt.quantity, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("CostFor(%d): %v", tt.quantity, err)
+			}
+			if cost.Amount != tt.want || cost.Currency != "eur" {
+				t.Errorf("CostFor(%d) = %v, want %d eur", tt.quantity, cost, tt.want)
+			}
+		})
+	}
+}
+
+func tiered(mode string, tiers []PriceTier) Price {
+	return Price{Key: "seats", BillingScheme: BillingSchemeTiered, TiersMode: mode, Tiers: tiers}
+}
+
+func TestListUsageIdempotencyKeysQuery(t *testing.T) {
+	var queries []url.Values
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		queries = append(queries, r.URL.Query())
+		w.Write([]byte(`{"idempotency_keys":["k1"]}`))
+	})
+	ctx := context.Background()
+	start := time.Date(2026, 1, 2, 3, 4, 5, 123456789, time.FixedZone("CET", 3600))
+
+	if _, err := c.Billing.ListUsageIdempotencyKeys(ctx, "sub_1", start, time.Time{}); err != nil {
+		t.Fatalf("ListUsageIdempotencyKeys: %v", err)
+	}
+	if got := queries[0].Get("start"); got != "2026-01-02T03:04:05.123456789+01:00" {
+		t.Errorf("start = %q, want nanosecond precision", got)
+	}
+	if queries[0].Has("end") {
+		t.Errorf("zero end sent as %q", queries[0].Get("end"))
+	}
+
+	if _, err := c.Billing.ListUsageIdempotencyKeys(ctx, "", start, time.Time{}); !errors.Is(err, ErrValidation) {
+		t.Errorf("empty subscription ID error = %v, want ErrValidation", err)
+	}
+	if len(queries) != 1 {
+		t.Errorf("made %d requests, want 1", len(queries))
+	}
+}
+
+func TestEntitlementCheckKeepsLargeIntegers(t *testing.T) {
+	var check EntitlementCheck
+	if err := json.Unmarshal([]byte(`{"has_access":true,"value":9007199254740993}`), &check); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	if check.Value != json.Number("9007199254740993") {
+		t.Errorf("Value = %#v, want exact json.Number", check.Value)
+	}
+	if n, ok := check.IntValue(); !ok || n != 9007199254740993 {
+		t.Errorf("IntValue() = %d, %v; want 9007199254740993, true", n, ok)
+	}
+
+	out, err := json.Marshal(&check)
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+	if !strings.Contains(string(out), `"value":9007199254740993`) {
+		t.Errorf("re-encoded check = %s, want the exact value", out)
+	}
+}
+
+func TestPaymentConfigSettingsKeepLargeIntegers(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"id":"pc_1","settings":{"account":9007199254740993,"fee":1.5}}`))
+	})
+
+	config, err := c.Billing.GetPaymentConfig(context.Background(), "pc_1")
+	if err != nil {
+		t.Fatalf("GetPaymentConfig: %v", err)
+	}
+	if got := config.Settings["account"]; got != json.Number("9007199254740993") {
+		t.Errorf("settings.account = %#v, want exact json.Number", got)
+	}
+	if got := config.Settings["fee"]; got != json.Number("1.5") {
+		t.Errorf("settings.fee = %#v, want json.Number", got)
+	}
+}
+
+func TestCanReactivate(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	deadline := func(d time.Duration) *Time { return &Time{Time: now.Add(d)} }
+	canceled := string(SubscriptionStatusCanceled)
+	active := string(SubscriptionStatusActive)
+
+	tests := []struct {
+		name string
+		sub  Subscription
+		want bool
+	}{
+		{"active", Subscription{Status: active}, false},
+		{"pending cancellation", Subscription{Status: active, CancelAtPeriodEnd: true}, true},
+		{"canceled within window", Subscription{Status: canceled, ReactivationDeadline: deadline(time.Hour)}, true},
+		{"canceled at deadline", Subscription{Status: canceled, ReactivationDeadline: deadline(0)}, false},
+		{"canceled past deadline", Subscription{Status: canceled, ReactivationDeadline: deadline(-time.Hour)}, false},
+		{"canceled without deadline", Subscription{Status: canceled}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.sub.canReactivateAt(now); got != tt.want {
+				t.Errorf("canReactivateAt = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDowngradeToFreeSkipsProration(t *testing.T) {
+	var body string
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/billing/v1/plans":
+			w.Write([]byte(`{"plans":[{"id":"plan_free","key":"free"}]}`))
+		case r.Method == "GET":
+			w.Write([]byte(`{"prices":[{"id":"price_free","key":"free_monthly_EUR"}]}`))
+		case r.Method == "PATCH":
+			b, _ := io.ReadAll(r.Body)
+			body = string(b)
+			w.Write([]byte(`{"id":"sub_1","price_id":"price_free"}`))
+		}
+	})
+
+	if _, err := c.Billing.DowngradeToFree(context.Background(), "sub_1"); err != nil {
+		t.Fatalf("DowngradeToFree: %v", err)
+	}
+	if body != `{"price_id":"price_free","proration_behavior":"none"}` {
+		t.Errorf("update body = %s", body)
+	}
+}
+
+func TestCustomerIterStopsAtFailedPageWhenContinuing(t *testing.T) {
+	var calls atomic.Int32
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			w.Write([]byte(`{"customers":[{"id":"cus_1"}],"next_cursor":"c2"}`))
+			return
+		}
+		http.Error(w, `{"code":"not_found","message":"cursor expired"}`, http.StatusNotFound)
+	})
+
+	it := c.Billing.ListCustomersIter(context.Background(), nil, WithStopOnError(false))
+	n := 0
+	for it.Next() {
+		n++
+	}
+	if n != 1 || !IsNotFound(it.Err()) {
+		t.Errorf("got %d customers and error %v, want 1 and not found", n, it.Err())
+	}
+	if it.Next() || calls.Load() != 2 {
+		t.Errorf("iterator continued after a failed page: %d requests", calls.Load())
+	}
+}
+
+func TestCanTransition(t *testing.T) {
+	statuses := []SubscriptionStatus{
+		SubscriptionStatusIncomplete,
+		SubscriptionStatusTrialing,
+		SubscriptionStatusActive,
+		SubscriptionStatusPastDue,
+		SubscriptionStatusPaused,
+		SubscriptionStatusCanceled,
+	}
+	// want lists every allowed transition; all others must be rejected.
+	want := map[[2]SubscriptionStatus]bool{
+		{SubscriptionStatusIncomplete, SubscriptionStatusTrialing}: true,
+		{SubscriptionStatusIncomplete, SubscriptionStatusActive}:   true,
+		{SubscriptionStatusIncomplete, SubscriptionStatusCanceled}: true,
+		{SubscriptionStatusTrialing, SubscriptionStatusActive}:     true,
+		{SubscriptionStatusTrialing, SubscriptionStatusPastDue}:    true,
+		{SubscriptionStatusTrialing, SubscriptionStatusPaused}:     true,
+		{SubscriptionStatusTrialing, SubscriptionStatusCanceled}:   true,
+		{SubscriptionStatusActive, SubscriptionStatusPastDue}:      true,
+		{SubscriptionStatusActive, SubscriptionStatusPaused}:       true,
+		{SubscriptionStatusActive, SubscriptionStatusCanceled}:     true,
+		{SubscriptionStatusPastDue, SubscriptionStatusActive}:      true,
+		{SubscriptionStatusPastDue, SubscriptionStatusCanceled}:    true,
+		{SubscriptionStatusPaused, SubscriptionStatusActive}:       true,
+		{SubscriptionStatusPaused, SubscriptionStatusCanceled}:     true,
+		{SubscriptionStatusCanceled, SubscriptionStatusActive}:     true,
+	}
+	for _, from := range statuses {
+		for _, to := range statuses {
+			if got := CanTransition(from, to); got != want[[2]SubscriptionStatus{from, to}] {
+				t.Errorf("CanTransition(%s, %s) = %v, want %v", from, to, got, !got)
+			}
+		}
+	}
+	if CanTransition("unknown", SubscriptionStatusActive) || CanTransition(SubscriptionStatusActive, "unknown") {
+		t.Errorf("CanTransition allowed an unknown status")
+	}
+}
+
+func TestRecordUsageMetadataBody(t *testing.T) {
+	var bodies []string
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		bodies = append(bodies, string(b))
+		w.Write([]byte(`{"id":"usage_1","metadata":{"feature":"export"}}`))
+	})
+	ctx := context.Background()
+
+	record, err := c.Billing.RecordUsage(ctx, &RecordUsageParams{SubscriptionID: "sub_1", Quantity: 2, Metadata: map[string]string{"feature": "export"}})
+	if err != nil {
+		t.Fatalf("RecordUsage: %v", err)
+	}
+	if record.Metadata["feature"] != "export" {
+		t.Errorf("decoded metadata = %v", record.Metadata)
+	}
+	if _, err := c.Billing.RecordUsage(ctx, &RecordUsageParams{SubscriptionID: "sub_1", Quantity: 2}); err != nil {
+		t.Fatalf("RecordUsage: %v", err)
+	}
+
+	want := []string{
+		`{"subscription_id":"sub_1","quantity":2,"metadata":{"feature":"export"}}`,
+		`{"subscription_id":"sub_1","quantity":2}`,
+	}
+	if !slices.Equal(bodies, want) {
+		t.Errorf("bodies = %q, want %q", bodies, want)
+	}
+
+	if _, err := c.Billing.RecordUsage(ctx, nil); !errors.Is(err, ErrValidation) {
+		t.Errorf("RecordUsage(nil) error = %v, want ErrValidation", err)
+	}
+	if len(bodies) != 2 {
+		t.Errorf("RecordUsage(nil) sent a request")
+	}
+}
+
+func TestUpsertCustomerCreateRace(t *testing.T) {
+	var requests []string
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method)
+		switch {
+		case r.Method == "GET" && len(requests) == 1:
+			w.Write([]byte(`{"customers":[]}`))
+		case r.Method == "POST":
+			http.Error(w, `{"code":"conflict","message":"external_id taken"}`, http.StatusConflict)
+		case r.Method == "GET":
+			w.Write([]byte(`{"customers":[{"id":"cus_1","external_id":"user:1","email":"jane@example.com"}]}`))
+		default:
+			t.Errorf("unexpected %s %s", r.Method, r.URL)
+		}
+	})
+
+	customer, created, err := c.Billing.UpsertCustomer(context.Background(), &UpsertCustomerParams{ExternalID: "user:1", Email: "jane@example.com"})
+	if err != nil {
+		t.Fatalf("UpsertCustomer: %v", err)
+	}
+	if created || customer.ID != "cus_1" {
+		t.Errorf("UpsertCustomer = %+v, created %v; want the concurrently created cus_1", customer, created)
+	}
+	if want := []string{"GET", "POST", "GET"}; !slices.Equal(requests, want) {
+		t.Errorf("requests = %q, want %q", requests, want)
+	}
+}
+
+func TestUpdateCustomerParamsMetadata(t *testing.T) {
+	tests := []struct {
+		name   string
+		params UpdateCustomerParams
+		want   string
+	}{
+		{"nil map", UpdateCustomerParams{}, `{}`},
+		{"empty map", UpdateCustomerParams{Metadata: map[string]string{}}, `{}`},
+		{"populated map", UpdateCustomerParams{Metadata: map[string]string{"tier": "gold"}}, `{"metadata":{"tier":"gold"}}`},
+		{"nil map cleared", UpdateCustomerParams{ClearMetadata: true}, `{"metadata":{}}`},
+		{"empty map cleared", UpdateCustomerParams{Metadata: map[string]string{}, ClearMetadata: true}, `{"metadata":{}}`},
+		{"populated map cleared", UpdateCustomerParams{Metadata: map[string]string{"tier": "gold"}, ClearMetadata: true}, `{"metadata":{}}`},
+	}
+	name := "Jane"
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, params := range []any{tt.params, &tt.params} {
+				got, err := json.Marshal(params)
+				if err != nil {
+					t.Fatalf("Marshal: %v", err)
+				}
+				if string(got) != tt.want {
+					t.Errorf("Marshal(%T) = %s, want %s", params, got, tt.want)
+				}
+			}
+
+			tt.params.Name = &name
+			got, err := json.Marshal(tt.params)
+			if err != nil {
+				t.Fatalf("Marshal: %v", err)
+			}
+			var fields map[string]json.RawMessage
+			if err := json.Unmarshal(got, &fields); err != nil || string(fields["name"]) != `"Jane"` {
+				t.Errorf("Marshal with Name = %s, want other fields kept", got)
+			}
+		})
+	}
+}
+
+func TestUpdateSubscriptionRequestBody(t *testing.T) {
+	var body string
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "PATCH" || r.URL.Path != "/billing/v1/subscriptions/sub_1" {
+			t.Errorf("request = %s %s, want PATCH /billing/v1/subscriptions/sub_1", r.Method, r.URL.Path)
+		}
+		b, _ := io.ReadAll(r.Body)
+		body = string(b)
+		w.Write([]byte(`{"id":"sub_1"}`))
+	})
+	pro, team, monthly, yearly := "pro", "team", "monthly", "price_yearly"
+	five, one := 5, 1
+	contract := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name   string
+		params *UpdateSubscriptionParams
+		want   string
+	}{
+		{
+			name:   "upgrade by plan key",
+			params: &UpdateSubscriptionParams{PlanKey: &team, ProrationBehavior: ProrationAlwaysInvoice},
+			want:   `{"plan_key":"team","proration_behavior":"always_invoice"}`,
+		},
+		{
+			name:   "downgrade by price ID",
+			params: &UpdateSubscriptionParams{PriceID: &yearly, ProrationBehavior: ProrationNone},
+			want:   `{"price_id":"price_yearly","proration_behavior":"none"}`,
+		},
+		{
+			name:   "plan and price key",
+			params: &UpdateSubscriptionParams{PlanKey: &pro, PriceKey: &monthly},
+			want:   `{"plan_key":"pro","price_key":"monthly"}`,
+		},
+		{
+			name:   "quantity change",
+			params: &UpdateSubscriptionParams{Quantity: &five, ProrationBehavior: ProrationCreateProrations},
+			want:   `{"quantity":5,"proration_behavior":"create_prorations"}`,
+		},
+		{
+			name:   "quantity one",
+			params: &UpdateSubscriptionParams{Quantity: &one},
+			want:   `{"quantity":1}`,
+		},
+		{
+			name:   "downgrade credited to the balance",
+			params: &UpdateSubscriptionParams{PlanKey: &pro, ProrationBehavior: ProrationCreditBalance},
+			want:   `{"plan_key":"pro","proration_behavior":"credit_balance"}`,
+		},
+		{
+			name:   "backdated to a contract date",
+			params: &UpdateSubscriptionParams{PlanKey: &team, EffectiveAt: &contract},
+			want:   `{"plan_key":"team","effective_at":"2026-10-01T00:00:00Z"}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := c.Billing.UpdateSubscription(context.Background(), "sub_1", tt.params); err != nil {
+				t.Fatalf("UpdateSubscription: %v", err)
+			}
+			if body != tt.want {
+				t.Errorf("body = %s, want %s", body, tt.want)
+			}
+		})
+	}
+}
+
+func TestChangeSubscriptionExpandPrices(t *testing.T) {
+	var requests []string
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.Path)
+		switch r.Method + " " + r.URL.Path {
+		case "GET /billing/v1/subscriptions/sub_1":
+			w.Write([]byte(`{"id":"sub_1","price_id":"price_pro","quantity":2}`))
+		case "PATCH /billing/v1/subscriptions/sub_1":
+			var params UpdateSubscriptionParams
+			json.NewDecoder(r.Body).Decode(&params)
+			priceID := "price_pro"
+			if params.PlanKey != nil {
+				priceID = "price_team"
+			}
+			fmt.Fprintf(w, `{"id":"sub_1","price_id":%q,"quantity":3}`, priceID)
+		case "GET /billing/v1/prices/price_pro":
+			w.Write([]byte(`{"id":"price_pro","amount":1000,"currency":"eur"}`))
+		case "GET /billing/v1/prices/price_team":
+			w.Write([]byte(`{"id":"price_team","amount":2500,"currency":"eur"}`))
+		default:
+			http.NotFound(w, r)
+		}
+	})
+	ctx := context.Background()
+	team := "team"
+	three := 3
+
+	result, err := c.Billing.ChangeSubscription(ctx, "sub_1", &ChangeSubscriptionParams{
+		UpdateSubscriptionParams: UpdateSubscriptionParams{PlanKey: &team, Quantity: &three},
+		ExpandPrices:             true,
+	})
+	if err != nil {
+		t.Fatalf("ChangeSubscription: %v", err)
+	}
+	oldAmount, newAmount, err := result.PriceChangeSummary()
+	if err != nil {
+		t.Fatalf("PriceChangeSummary: %v", err)
+	}
+	if oldAmount != (Money{Amount: 2000, Currency: "eur"}) || newAmount != (Money{Amount: 7500, Currency: "eur"}) {
+		t.Errorf("PriceChangeSummary = %+v, %+v; want 2000 and 7500 eur", oldAmount, newAmount)
+	}
+	want := []string{
+		"GET /billing/v1/subscriptions/sub_1",
+		"PATCH /billing/v1/subscriptions/sub_1",
+		"GET /billing/v1/prices/price_pro",
+		"GET /billing/v1/prices/price_team",
+	}
+	if !slices.Equal(requests, want) {
+		t.Errorf("requests = %q, want %q", requests, want)
+	}
+
+	// A quantity change keeps the price, which is fetched once.
+	requests = nil
+	result, err = c.Billing.ChangeSubscription(ctx, "sub_1", &ChangeSubscriptionParams{
+		UpdateSubscriptionParams: UpdateSubscriptionParams{Quantity: &three},
+		ExpandPrices:             true,
+	})
+	if err != nil {
+		t.Fatalf("ChangeSubscription: %v", err)
+	}
+	if len(requests) != 3 || result.PreviousPrice != result.Price {
+		t.Errorf("requests = %q, want the unchanged price fetched once", requests)
+	}
+
+	requests = nil
+	result, err = c.Billing.ChangeSubscription(ctx, "sub_1", &ChangeSubscriptionParams{UpdateSubscriptionParams: UpdateSubscriptionParams{Quantity: &three}})
+	if err != nil {
+		t.Fatalf("ChangeSubscription: %v", err)
+	}
+	if len(requests) != 1 || result.Subscription.Quantity != 3 {
+		t.Errorf("without ExpandPrices requests = %q, result = %+v", requests, result)
+	}
+	if _, _, err := result.PriceChangeSummary(); !errors.Is(err, ErrPricesNotExpanded) {
+		t.Errorf("PriceChangeSummary error = %v, want ErrPricesNotExpanded", err)
+	}
+	if _, err := c.Billing.ChangeSubscription(ctx, "sub_1", nil); !errors.Is(err, ErrValidation) {
+		t.Errorf("ChangeSubscription(nil) error = %v, want ErrValidation", err)
+	}
+}
+
+func TestEffectiveAtTooFarInThePast(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+	})
+	ctx := context.Background()
+	longAgo := time.Now().Add(-MaxEffectiveAtBackdate - time.Hour)
+
+	if _, err := c.Billing.UpdateSubscription(ctx, "sub_1", &UpdateSubscriptionParams{EffectiveAt: &longAgo}); !errors.Is(err, ErrValidation) {
+		t.Errorf("UpdateSubscription error = %v, want ErrValidation", err)
+	}
+	if _, err := c.Billing.PreviewSubscriptionChange(ctx, "sub_1", &PreviewSubscriptionChangeParams{EffectiveAt: &longAgo}); !errors.Is(err, ErrValidation) {
+		t.Errorf("PreviewSubscriptionChange error = %v, want ErrValidation", err)
+	}
+}
+
+func TestPauseSubscriptionRequestBody(t *testing.T) {
+	var bodies []string
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		bodies = append(bodies, r.URL.Path+" "+string(b))
+		w.Write([]byte(`{"id":"sub_1"}`))
+	})
+	ctx := context.Background()
+	resumesAt := time.Date(2026, 12, 1, 9, 0, 0, 0, time.UTC)
+
+	if _, err := c.Billing.PauseSubscription(ctx, "sub_1", nil); err != nil {
+		t.Fatalf("PauseSubscription: %v", err)
+	}
+	if _, err := c.Billing.PauseSubscription(ctx, "sub_1", &PauseSubscriptionParams{ResumesAt: &resumesAt}); err != nil {
+		t.Fatalf("PauseSubscription: %v", err)
+	}
+	if _, err := c.Billing.ResumeSubscription(ctx, "sub_1"); err != nil {
+		t.Fatalf("ResumeSubscription: %v", err)
+	}
+
+	want := []string{
+		"/billing/v1/subscriptions/sub_1/pause {}",
+		`/billing/v1/subscriptions/sub_1/pause {"resumes_at":"2026-12-01T09:00:00Z"}`,
+		"/billing/v1/subscriptions/sub_1/resume ",
+	}
+	if !slices.Equal(bodies, want) {
+		t.Errorf("requests = %q, want %q", bodies, want)
+	}
+}
+
+func TestReactivateSubscriptionErrors(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/billing/v1/subscriptions/sub_1/reactivate" {
+			t.Errorf("request = %s %s", r.Method, r.URL.Path)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusConflict)
+		w.Write([]byte(`{"code":"reactivation_expired","message":"the reactivation window has passed"}`))
+	})
+
+	_, err := c.Billing.ReactivateSubscription(context.Background(), "sub_1")
+	if !errors.Is(err, ErrReactivationExpired) {
+		t.Errorf("error = %v, want ErrReactivationExpired", err)
+	}
+	if errors.Is(err, ErrSubscriptionPaused) {
+		t.Errorf("error = %v matched ErrSubscriptionPaused", err)
+	}
+	if _, err := c.Billing.ReactivateSubscription(context.Background(), " "); !errors.Is(err, ErrValidation) {
+		t.Errorf("blank ID error = %v, want ErrValidation", err)
+	}
+}
+
+func TestPreviewSubscriptionChangeLineItems(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/billing/v1/subscriptions/sub_1/preview" {
+			t.Errorf("request = %s %s", r.Method, r.URL.Path)
+		}
+		b, _ := io.ReadAll(r.Body)
+		if got, want := string(b), `{"price_id":"price_team","quantity":3}`; got != want {
+			t.Errorf("body = %s, want %s", got, want)
+		}
+		w.Write([]byte(`{
+			"amount_due_now": 4497,
+			"gross_amount": 5997,
+			"net_amount": 4497,
+			"rounding": "half_even",
+			"currency": "EUR",
+			"proration_date": "2026-10-17T12:00:00Z",
+			"line_items": [
+				{"description": "Unused time on Pro", "amount": -1500, "price_id": "price_pro", "proration": true},
+				{"description": "Remaining time on Team", "amount": 5997, "quantity": 3, "price_id": "price_team", "proration": true}
+			]
+		}`))
+	})
+
+	preview, err := c.Billing.PreviewSubscriptionChange(context.Background(), "sub_1", &PreviewSubscriptionChangeParams{PriceID: "price_team", Quantity: 3})
+	if err != nil {
+		t.Fatalf("PreviewSubscriptionChange: %v", err)
+	}
+	want := []SubscriptionChangeLineItem{
+		{Description: "Unused time on Pro", Amount: -1500, PriceID: "price_pro", Proration: true},
+		{Description: "Remaining time on Team", Amount: 5997, Quantity: 3, PriceID: "price_team", Proration: true},
+	}
+	if !slices.Equal(preview.LineItems, want) {
+		t.Errorf("LineItems = %+v, want %+v", preview.LineItems, want)
+	}
+	if preview.Rounding != RoundingHalfEven || preview.Gross() != (Money{Amount: 5997, Currency: "EUR"}) || preview.Net() != (Money{Amount: 4497, Currency: "EUR"}) {
+		t.Errorf("preview = %+v", preview)
+	}
+	if !preview.ProrationDate.Equal(time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC)) {
+		t.Errorf("ProrationDate = %v", preview.ProrationDate)
+	}
+}
+
+func TestGetPricePaths(t *testing.T) {
+	var paths []string
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.Method+" "+r.URL.EscapedPath())
+		w.Write([]byte(`{"id":"price_1","plan_id":"plan_1","amount":999}`))
+	})
+	ctx := context.Background()
+
+	if _, err := c.Billing.GetPrice(ctx, "plan_1", "price_1"); err != nil {
+		t.Fatalf("GetPrice: %v", err)
+	}
+	if _, err := c.Billing.GetPriceByID(ctx, "price/1"); err != nil {
+		t.Fatalf("GetPriceByID: %v", err)
+	}
+	want := []string{
+		"GET /billing/v1/plans/plan_1/prices/price_1",
+		"GET /billing/v1/prices/price%2F1",
+	}
+	if !slices.Equal(paths, want) {
+		t.Errorf("requests = %q, want %q", paths, want)
+	}
+
+	for name, get := range map[string]func() error{
+		"GetPrice blank plan":   func() error { _, err := c.Billing.GetPrice(ctx, "", "price_1"); return err },
+		"GetPrice blank price":  func() error { _, err := c.Billing.GetPrice(ctx, "plan_1", ""); return err },
+		"GetPriceByID blank ID": func() error { _, err := c.Billing.GetPriceByID(ctx, ""); return err },
+	} {
+		if err := get(); !errors.Is(err, ErrValidation) {
+			t.Errorf("%s error = %v, want ErrValidation", name, err)
+		}
+	}
+	if len(paths) != 2 {
+		t.Errorf("server saw %d requests, want 2", len(paths))
+	}
+}
+
+func TestCheckEntitlementForCustomersRetriesRateLimited(t *testing.T) {
+	var mu sync.Mutex
+	attempts := map[string]int{}
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		var params CheckEntitlementParams
+		json.NewDecoder(r.Body).Decode(&params)
+		mu.Lock()
+		attempts[params.CustomerID]++
+		n := attempts[params.CustomerID]
+		mu.Unlock()
+		if params.CustomerID == "cus_3" && n == 1 {
+			w.Header().Set("Retry-After", "1")
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusTooManyRequests)
+			w.Write([]byte(`{"code":"rate_limited","message":"slow down"}`))
+			return
+		}
+		w.Write([]byte(`{"has_access":true}`))
+	}).WithMaxRetries(2).WithMaxRetryAfter(time.Millisecond)
+
+	ids := []string{"cus_1", "cus_2", "cus_3", "cus_4", "cus_5"}
+	checks, err := c.Billing.CheckEntitlementForCustomers(context.Background(), ids, "seats", WithConcurrency(2))
+	if err != nil {
+		t.Fatalf("CheckEntitlementForCustomers: %v", err)
+	}
+	if !checks.Complete() || len(checks.Checks) != len(ids) {
+		t.Errorf("checks = %+v, want all %d customers", checks, len(ids))
+	}
+	if attempts["cus_3"] != 2 {
+		t.Errorf("cus_3 was attempted %d times, want 2", attempts["cus_3"])
+	}
+
+	// Without retries the 429 fails the batch.
+	attempts = map[string]int{}
+	_, err = c.WithMaxRetries(0).Billing.CheckEntitlementForCustomers(context.Background(), ids, "seats", WithConcurrency(1))
+	if !IsRateLimited(err) {
+		t.Errorf("without retries error = %v, want a 429", err)
+	}
+}
+
+func TestUpsertCustomerValidatesParams(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+	})
+	for name, params := range map[string]*UpsertCustomerParams{
+		"nil params":          nil,
+		"missing external ID": {Email: "jane@example.com"},
+	} {
+		if _, _, err := c.Billing.UpsertCustomer(context.Background(), params); !errors.Is(err, ErrValidation) {
+			t.Errorf("%s: error = %v, want ErrValidation", name, err)
+		}
+	}
+}
+
+func TestOverageCost(t *testing.T) {
+	included := 1000
+	tests := []struct {
+		name  string
+		ent   Entitlement
+		units int
+		want  int
+	}{
+		{"zero overage", Entitlement{OveragePrice: 200, OverageUnit: 500}, 0, 0},
+		{"usage below the included amount", Entitlement{ValueInt: &included, OveragePrice: 200, OverageUnit: 500}, 800 - included, 0},
+		{"one exact block", Entitlement{OveragePrice: 200, OverageUnit: 500}, 500, 200},
+		{"several exact blocks", Entitlement{OveragePrice: 200, OverageUnit: 500}, 1500, 600},
+		{"partial block rounds up", Entitlement{OveragePrice: 200, OverageUnit: 500}, 1, 200},
+		{"block and a unit rounds up", Entitlement{OveragePrice: 200, OverageUnit: 500}, 1001, 600},
+		{"unit of zero is one", Entitlement{OveragePrice: 3}, 7, 21},
+		{"no overage price", Entitlement{OverageUnit: 500}, 1500, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.ent.OverageCost(tt.units); got != (Money{Amount: tt.want}) {
+				t.Errorf("OverageCost(%d) = %+v, want %d", tt.units, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEntitlementCheckValueAccessors(t *testing.T) {
+	type want struct {
+		i      int
+		iOK    bool
+		b, bOK bool
+		s      string
+		sOK    bool
+	}
+	tests := []struct {
+		name  string
+		value any
+		want  want
+	}{
+		{"int as float", float64(25), want{i: 25, iOK: true}},
+		{"int as json.Number", json.Number("25"), want{i: 25, iOK: true}},
+		{"int", 25, want{i: 25, iOK: true}},
+		{"int64", int64(25), want{i: 25, iOK: true}},
+		{"integral float json.Number", json.Number("25.0"), want{i: 25, iOK: true}},
+		{"fractional float", 2.5, want{}},
+		{"fractional json.Number", json.Number("2.5"), want{}},
+		{"float out of range", 1e300, want{}},
+		{"bool", true, want{b: true, bOK: true}},
+		{"false", false, want{bOK: true}},
+		{"string", "eu-west", want{s: "eu-west", sOK: true}},
+		{"numeric string", "25", want{s: "25", sOK: true}},
+		{"nil", nil, want{}},
+		{"object", map[string]any{"limit": 25}, want{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			check := EntitlementCheck{Value: tt.value}
+			var got want
+			got.i, got.iOK = check.IntValue()
+			got.b, got.bOK = check.BoolValue()
+			got.s, got.sOK = check.StringValue()
+			if got != tt.want {
+				t.Errorf("accessors of %#v = %+v, want %+v", tt.value, got, tt.want)
+			}
+		})
+	}
+
+	// Decoded values go through the same accessors.
+	var check EntitlementCheck
+	if err := json.Unmarshal([]byte(`{"value":25}`), &check); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	if n, ok := check.IntValue(); !ok || n != 25 {
+		t.Errorf("decoded IntValue() = %d, %v; want 25, true", n, ok)
+	}
+	if _, ok := check.StringValue(); ok {
+		t.Errorf("decoded StringValue() of a number reported ok")
+	}
+}
@@ -0,0 +1,115 @@
+package tedo
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+)
+
+// defaultDebugBodyLimit is the number of body bytes WithDebug dumps before
+// truncating.
+const defaultDebugBodyLimit = 64 << 10
+
+// debugWriter serializes dumps so that concurrent calls don't interleave.
+// Clients cloned from one another share mu along with w.
+type debugWriter struct {
+	mu        *sync.Mutex
+	w         io.Writer
+	bodyLimit int
+}
+
+// WithDebug dumps every request attempt and its response to w: method, URL,
+// headers and body going out, status, headers and body coming back. The
+// Authorization header is redacted, and bodies longer than 64 KiB are
+// truncated (see WithDebugBodyLimit). Each attempt is written with a single
+// Write call. Dumps contain customer data; don't enable this in production.
+func (c *Client) WithDebug(w io.Writer) *Client {
+	return c.derive(func(c *Client) {
+		limit := defaultDebugBodyLimit
+		if c.debug != nil {
+			limit = c.debug.bodyLimit
+		}
+		c.debug = &debugWriter{mu: new(sync.Mutex), w: w, bodyLimit: limit}
+	})
+}
+
+// WithDebugBodyLimit sets how many bytes of each body WithDebug dumps before
+// truncating it; a negative n disables truncation. Call it after WithDebug.
+func (c *Client) WithDebugBodyLimit(n int) *Client {
+	return c.derive(func(c *Client) {
+		if c.debug != nil {
+			c.debug = &debugWriter{mu: c.debug.mu, w: c.debug.w, bodyLimit: n}
+		}
+	})
+}
+
+// wrap dumps the requests sent through next. It runs innermost, after all
+// middlewares, so the dump shows what actually goes on the wire.
+func (d *debugWriter) wrap(next RoundTripFunc) RoundTripFunc {
+	return func(req *http.Request) (*http.Response, error) {
+		var buf bytes.Buffer
+		fmt.Fprintf(&buf, "--- tedo request (attempt %d) ---\n", RequestAttempt(req))
+		fmt.Fprintf(&buf, "%s %s\n", req.Method, req.URL)
+		header := req.Header.Clone()
+		if header.Get("Authorization") != "" {
+			header.Set("Authorization", "Bearer tedo_***")
+		}
+		header.Write(&buf)
+		buf.WriteString("\n")
+		switch {
+		case req.Body == nil || req.Body == http.NoBody:
+		case req.GetBody != nil:
+			if body, err := req.GetBody(); err == nil {
+				b, _ := io.ReadAll(body)
+				d.writeBody(&buf, b)
+			}
+		default:
+			buf.WriteString("[streamed body not shown]\n")
+		}
+
+		resp, err := next(req)
+		buf.WriteString("--- tedo response ---\n")
+		if err != nil {
+			fmt.Fprintf(&buf, "error: %v\n", err)
+			d.write(buf.Bytes())
+			return nil, err
+		}
+
+		fmt.Fprintf(&buf, "%s %s\n", resp.Proto, resp.Status)
+		resp.Header.Write(&buf)
+		buf.WriteString("\n")
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		d.writeBody(&buf, body)
+		if err != nil {
+			fmt.Fprintf(&buf, "error reading body: %v\n", err)
+			d.write(buf.Bytes())
+			return nil, err
+		}
+		d.write(buf.Bytes())
+		resp.Body = io.NopCloser(bytes.NewReader(body))
+		return resp, nil
+	}
+}
+
+// writeBody appends body to buf, truncated to the body limit.
+func (d *debugWriter) writeBody(buf *bytes.Buffer, body []byte) {
+	if len(body) == 0 {
+		return
+	}
+	if d.bodyLimit >= 0 && len(body) > d.bodyLimit {
+		buf.Write(body[:d.bodyLimit])
+		fmt.Fprintf(buf, "\n[truncated %d of %d bytes]\n", len(body)-d.bodyLimit, len(body))
+		return
+	}
+	buf.Write(body)
+	buf.WriteString("\n")
+}
+
+func (d *debugWriter) write(dump []byte) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.w.Write(dump)
+}
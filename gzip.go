@@ -0,0 +1,51 @@
+package tedo
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// decompress wraps next to decode gzip-encoded responses. The client asks
+// for gzip explicitly, which turns off net/http's transparent decoding, so
+// it has to decode them itself. Decoding errors are reported as such
+// rather than surfacing as malformed JSON.
+func decompress(next RoundTripFunc) RoundTripFunc {
+	return func(req *http.Request) (*http.Response, error) {
+		resp, err := next(req)
+		if err != nil || resp.Header.Get("Content-Encoding") != "gzip" {
+			return resp, err
+		}
+
+		zr, err := gzip.NewReader(resp.Body)
+		if err != nil {
+			resp.Body.Close()
+			return nil, fmt.Errorf("decompress response: %w", err)
+		}
+		resp.Body = &gzipBody{zr: zr, body: resp.Body}
+		resp.Header.Del("Content-Encoding")
+		resp.Header.Del("Content-Length")
+		resp.ContentLength = -1
+		resp.Uncompressed = true
+		return resp, nil
+	}
+}
+
+// gzipBody is a decoded response body.
+type gzipBody struct {
+	zr   *gzip.Reader
+	body io.ReadCloser
+}
+
+func (b *gzipBody) Read(p []byte) (int, error) {
+	n, err := b.zr.Read(p)
+	if err != nil && err != io.EOF {
+		err = fmt.Errorf("decompress response: %w", err)
+	}
+	return n, err
+}
+
+func (b *gzipBody) Close() error {
+	return b.body.Close()
+}
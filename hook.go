@@ -0,0 +1,102 @@
+package tedo
+
+import "context"
+
+// CallInfo describes a logical API call to a CallHook.
+type CallInfo struct {
+	// Operation names the service method, e.g. "billing.CreateSubscription".
+	// It is empty for calls made with Do.
+	Operation string
+
+	Method string
+
+	// PathTemplate is the request path with IDs left as placeholders, e.g.
+	// "/billing/v1/subscriptions/{subscriptionID}", so it is safe to use as
+	// a low-cardinality label. For calls made with Do it is the path as
+	// given.
+	PathTemplate string
+}
+
+// CallResult is the outcome of a call reported to a CallHook.
+type CallResult struct {
+	StatusCode int // of the final attempt, zero if no response was received
+	Attempts   int // requests made, including retries
+	Err        error
+}
+
+// CallHook observes API calls, e.g. to trace them. It is invoked when a call
+// starts, before any attempt is sent, and returns the context to make the
+// call with (e.g. carrying a span) and a function that is invoked once with
+// the result when the call ends. Retries of a call are not separate calls.
+type CallHook func(ctx context.Context, call CallInfo) (context.Context, func(CallResult))
+
+// WithCallHook registers a hook invoked around every API call. Hooks are
+// invoked in registration order and their end functions in reverse. Without
+// hooks, calls carry no instrumentation overhead.
+func (c *Client) WithCallHook(hook CallHook) *Client {
+	return c.derive(WithCallHook(hook))
+}
+
+// WithCallHook registers a call hook at construction; see
+// Client.WithCallHook.
+func WithCallHook(hook CallHook) ClientOption {
+	return func(c *Client) {
+		c.callHooks = append(c.callHooks, hook)
+	}
+}
+
+type operationContextKey struct{}
+
+// operation identifies the service method behind a request.
+type operation struct {
+	name         string
+	pathTemplate string
+}
+
+// op annotates ctx with the operation name and path of a service call when
+// the client is instrumented, returning the path unchanged.
+func (s *BillingService) op(ctx context.Context, name, path string) (context.Context, string) {
+	if s.client.instrumented() {
+		ctx = context.WithValue(ctx, operationContextKey{}, operation{"billing." + name, path})
+	}
+	return ctx, path
+}
+
+// route is op for paths built from a template with pathf.
+func (s *BillingService) route(ctx context.Context, name, template string, values ...string) (context.Context, string, error) {
+	path, err := pathf(template, values...)
+	if err != nil {
+		return ctx, "", err
+	}
+	ctx, _ = s.op(ctx, name, template)
+	return ctx, path, nil
+}
+
+// instrumented reports whether calls need to be annotated with their
+// operation.
+func (c *Client) instrumented() bool {
+	return len(c.callHooks) > 0 || c.metrics != nil
+}
+
+// startCall invokes the call hooks, returning the context to make the call
+// with and a function that reports its result.
+func (c *Client) startCall(ctx context.Context, method, path string) (context.Context, func(CallResult)) {
+	call := CallInfo{Method: method, PathTemplate: path}
+	if op, ok := ctx.Value(operationContextKey{}).(operation); ok {
+		call.Operation, call.PathTemplate = op.name, op.pathTemplate
+	}
+
+	ends := make([]func(CallResult), 0, len(c.callHooks))
+	for _, hook := range c.callHooks {
+		var end func(CallResult)
+		ctx, end = hook(ctx, call)
+		if end != nil {
+			ends = append(ends, end)
+		}
+	}
+	return ctx, func(result CallResult) {
+		for i := len(ends) - 1; i >= 0; i-- {
+			ends[i](result)
+		}
+	}
+}
@@ -0,0 +1,100 @@
+package tedo
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// WithLogger enables request logging. Every request is logged at Debug level
+// with its method, path, status, duration and retry count, and failed
+// requests are additionally logged at Warn (4xx) or Error (5xx and network
+// errors) level. Headers, and so the API key, are never logged.
+func (c *Client) WithLogger(logger *slog.Logger) *Client {
+	return c.derive(WithLogger(logger))
+}
+
+// WithLogBodies includes JSON request bodies and query parameter values in
+// log entries. Both may contain customer data such as email addresses, so
+// this is off by default and logged paths show query parameter names only.
+func (c *Client) WithLogBodies(enabled bool) *Client {
+	return c.derive(func(c *Client) {
+		c.logBodies = enabled
+	})
+}
+
+// logRequest logs the outcome of a request after all attempts.
+func (c *Client) logRequest(ctx context.Context, method, path string, body []byte, status int, duration time.Duration, attempts int, err error) {
+	if c.logger == nil {
+		return
+	}
+
+	attrs := []slog.Attr{
+		slog.String("method", method),
+		slog.String("path", c.logPath(path)),
+		slog.Int("status", status),
+		slog.Duration("duration", duration),
+		slog.Int("retries", attempts-1),
+	}
+	if c.logBodies && body != nil {
+		attrs = append(attrs, slog.String("request_body", string(body)))
+	}
+	c.logger.LogAttrs(ctx, slog.LevelDebug, "tedo: request", attrs...)
+
+	if err == nil {
+		return
+	}
+	level := slog.LevelError
+	if status >= 400 && status < 500 {
+		level = slog.LevelWarn
+	}
+	attrs = append(attrs, slog.String("error", c.logError(err)))
+	c.logger.LogAttrs(ctx, level, "tedo: request failed", attrs...)
+}
+
+// logDryRun logs a mutating request suppressed by dry-run mode.
+func (c *Client) logDryRun(ctx context.Context, method, path string, body []byte) {
+	if c.logger == nil {
+		return
+	}
+
+	attrs := []slog.Attr{
+		slog.String("method", method),
+		slog.String("path", c.logPath(path)),
+		slog.Bool("dry_run", true),
+	}
+	if c.logBodies && body != nil {
+		attrs = append(attrs, slog.String("request_body", string(body)))
+	}
+	c.logger.LogAttrs(ctx, slog.LevelInfo, "tedo: request suppressed", attrs...)
+}
+
+// logPath returns path as it should be logged: with query parameter values
+// replaced by "REDACTED" unless WithLogBodies is enabled.
+func (c *Client) logPath(path string) string {
+	base, query, ok := strings.Cut(path, "?")
+	if !ok || c.logBodies {
+		return path
+	}
+	params := strings.Split(query, "&")
+	for i, param := range params {
+		name, _, _ := strings.Cut(param, "=")
+		params[i] = name + "=REDACTED"
+	}
+	return base + "?" + strings.Join(params, "&")
+}
+
+// logError returns the message of err as it should be logged. Network
+// errors quote the request URL, so its query values are redacted as in
+// logPath.
+func (c *Client) logError(err error) string {
+	msg := err.Error()
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) && urlErr.URL != "" {
+		msg = strings.ReplaceAll(msg, urlErr.URL, c.logPath(urlErr.URL))
+	}
+	return msg
+}
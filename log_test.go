@@ -0,0 +1,85 @@
+package tedo
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestLogsRedactSecrets(t *testing.T) {
+	var logs, dump bytes.Buffer
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			http.Error(w, `{"code":"invalid","message":"bad"}`, http.StatusBadRequest)
+			return
+		}
+		w.Write([]byte(`{"customers":[{"id":"cus_1","email":"jane@example.com"}]}`))
+	}).
+		WithLogger(slog.New(slog.NewJSONHandler(&logs, &slog.HandlerOptions{Level: slog.LevelDebug}))).
+		WithDebug(&dump)
+
+	ctx := context.Background()
+	if _, err := c.Billing.GetCustomerByEmail(ctx, "jane@example.com"); err != nil {
+		t.Fatalf("GetCustomerByEmail: %v", err)
+	}
+	c.Billing.ListCustomers(ctx, nil, WithQuery("email", "jane@example.com"))
+	c.Billing.CreateCustomer(ctx, &CreateCustomerParams{Email: "jane@example.com"})
+
+	if strings.Contains(logs.String(), "tedo_test_key") || strings.Contains(dump.String(), "tedo_test_key") {
+		t.Errorf("API key leaked:\nlogs: %s\ndump: %s", logs.String(), dump.String())
+	}
+	if strings.Contains(logs.String(), "jane@example.com") {
+		t.Errorf("query or body values logged without WithLogBodies:\n%s", logs.String())
+	}
+	if !strings.Contains(logs.String(), `customers?email=REDACTED`) {
+		t.Errorf("logged path lacks redacted query:\n%s", logs.String())
+	}
+	if !strings.Contains(dump.String(), "Authorization: Bearer tedo_***") {
+		t.Errorf("debug dump lacks redacted Authorization header:\n%s", dump.String())
+	}
+}
+
+func TestLogPathWithLogBodies(t *testing.T) {
+	c := NewClient("tedo_test_key")
+	if got := c.logPath("/v1/customers"); got != "/v1/customers" {
+		t.Errorf("logPath without query = %q", got)
+	}
+	if got, want := c.logPath("/v1/customers?email=a%40b.c&limit=5"), "/v1/customers?email=REDACTED&limit=REDACTED"; got != want {
+		t.Errorf("logPath = %q, want %q", got, want)
+	}
+	c = c.WithLogBodies(true)
+	if got, want := c.logPath("/v1/customers?email=a%40b.c"), "/v1/customers?email=a%40b.c"; got != want {
+		t.Errorf("logPath with bodies = %q, want %q", got, want)
+	}
+}
+
+func TestLogsRedactNetworkErrorURL(t *testing.T) {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	srv.Close()
+
+	for _, logBodies := range []bool{false, true} {
+		var logs bytes.Buffer
+		c := NewClient("tedo_test_key", WithBaseURL(srv.URL)).
+			WithLogger(slog.New(slog.NewJSONHandler(&logs, nil))).
+			WithLogBodies(logBodies)
+
+		_, err := c.Billing.GetCustomerByEmail(context.Background(), "jane@example.com")
+		if err == nil {
+			t.Fatal("expected a network error")
+		}
+		if !strings.Contains(err.Error(), "jane%40example.com") {
+			t.Fatalf("returned error = %v, want the full URL", err)
+		}
+		leaked := strings.Contains(logs.String(), "jane%40example.com")
+		if leaked != logBodies {
+			t.Errorf("WithLogBodies(%v): email in logged error = %v:\n%s", logBodies, leaked, logs.String())
+		}
+		if !logBodies && !strings.Contains(logs.String(), `customers?email=REDACTED`) {
+			t.Errorf("logged error lacks the redacted URL:\n%s", logs.String())
+		}
+	}
+}
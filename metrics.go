@@ -0,0 +1,40 @@
+package tedo
+
+import (
+	"context"
+	"time"
+)
+
+// MetricsObserver receives the outcome of every request attempt, e.g. to
+// feed Prometheus counters and latency histograms. Retries are observed as
+// separate attempts. Implementations must be safe for concurrent use.
+type MetricsObserver interface {
+	// ObserveRequest is called once per attempt. op names the service
+	// method, e.g. "billing.CreateSubscription", and is empty for calls made
+	// with Do. status is zero when no response was received, in which case
+	// err is the transport error.
+	ObserveRequest(op, method string, status int, duration time.Duration, err error)
+}
+
+// WithMetricsObserver reports every request attempt to obs. A panicking
+// observer does not affect the request.
+func WithMetricsObserver(obs MetricsObserver) ClientOption {
+	return func(c *Client) {
+		c.metrics = obs
+	}
+}
+
+// observeAttempt reports an attempt to the metrics observer, if any.
+func (c *Client) observeAttempt(ctx context.Context, method string, status int, duration time.Duration, err error) {
+	if c.metrics == nil {
+		return
+	}
+	defer func() {
+		if r := recover(); r != nil && c.logger != nil {
+			c.logger.ErrorContext(ctx, "tedo: metrics observer panicked", "panic", r)
+		}
+	}()
+
+	op, _ := ctx.Value(operationContextKey{}).(operation)
+	c.metrics.ObserveRequest(op.name, method, status, duration, err)
+}
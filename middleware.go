@@ -0,0 +1,51 @@
+package tedo
+
+import (
+	"context"
+	"net/http"
+)
+
+// RoundTripFunc sends a single HTTP request to the API.
+type RoundTripFunc func(req *http.Request) (*http.Response, error)
+
+// Middleware wraps the sending of each request attempt. It may modify the
+// outgoing request (e.g. add tracing headers) before calling next, and
+// observe the response or transport error next returns. Use RequestAttempt
+// to tell retries apart.
+type Middleware func(next RoundTripFunc) RoundTripFunc
+
+// Use registers middlewares. They run in registration order: the first
+// registered sees the request first and the response last.
+func (c *Client) Use(middlewares ...Middleware) *Client {
+	return c.derive(WithMiddleware(middlewares...))
+}
+
+// WithMiddleware registers middlewares at construction; see Client.Use.
+func WithMiddleware(middlewares ...Middleware) ClientOption {
+	return func(c *Client) {
+		c.middlewares = append(c.middlewares, middlewares...)
+	}
+}
+
+type attemptContextKey struct{}
+
+// RequestAttempt returns the 1-based attempt number of a request passed to a
+// Middleware, or 0 for requests not sent by the client.
+func RequestAttempt(req *http.Request) int {
+	attempt, _ := req.Context().Value(attemptContextKey{}).(int)
+	return attempt
+}
+
+// roundTrip sends req through the registered middlewares.
+func (c *Client) roundTrip(req *http.Request, attempt int) (*http.Response, error) {
+	req = req.WithContext(context.WithValue(req.Context(), attemptContextKey{}, attempt))
+
+	rt := decompress(c.httpClient.Do)
+	if c.debug != nil {
+		rt = c.debug.wrap(rt)
+	}
+	for i := len(c.middlewares) - 1; i >= 0; i-- {
+		rt = c.middlewares[i](rt)
+	}
+	return rt(req)
+}
@@ -0,0 +1,82 @@
+package tedo
+
+import (
+	"fmt"
+	"math"
+)
+
+// Money is an amount in the smallest unit of a currency (e.g. cents).
+type Money struct {
+	Amount   int    `json:"amount"`
+	Currency string `json:"currency,omitempty"`
+}
+
+// String formats the amount in major units, e.g. "12.50 EUR".
+func (m Money) String() string {
+	sign := ""
+	amount := m.Amount
+	if amount < 0 {
+		sign = "-"
+		amount = -amount
+	}
+	if m.Currency == "" {
+		return fmt.Sprintf("%s%d.%02d", sign, amount/100, amount%100)
+	}
+	return fmt.Sprintf("%s%d.%02d %s", sign, amount/100, amount%100, m.Currency)
+}
+
+// WithFXRates sets exchange rates used by ConvertMoney, as units of each
+// currency per one unit of a common base currency, e.g.
+// {"EUR": 1, "USD": 1.08, "GBP": 0.86}. The rates are the caller's own; the
+// API is not consulted.
+func (c *Client) WithFXRates(rates map[string]float64) *Client {
+	return c.derive(func(c *Client) {
+		c.fxRates = make(map[string]float64, len(rates))
+		for currency, rate := range rates {
+			c.fxRates[currency] = rate
+		}
+	})
+}
+
+// WithFXRounding sets how ConvertMoney rounds to the minor unit:
+// RoundingHalfUp, the default, or RoundingHalfEven, e.g. to match the
+// rounding reported by SubscriptionChangePreview. ConvertMoney fails for
+// any other mode.
+func (c *Client) WithFXRounding(mode string) *Client {
+	return c.derive(func(c *Client) {
+		c.fxRounding = mode
+	})
+}
+
+// ConvertMoney converts m to toCurrency using the rates set with WithFXRates.
+// The result is rounded to the nearest minor unit as set with WithFXRounding,
+// by default with halves rounded away from zero. Both currencies are assumed
+// to use the same minor unit (e.g. cents).
+//
+// Conversion is for display and reporting only, such as showing MRR in one
+// currency. Never use it to compute amounts to charge.
+func (c *Client) ConvertMoney(m Money, toCurrency string) (Money, error) {
+	if m.Currency == toCurrency {
+		return m, nil
+	}
+	from, ok := c.fxRates[m.Currency]
+	if !ok || from <= 0 {
+		return Money{}, fmt.Errorf("tedo: no exchange rate for %q", m.Currency)
+	}
+	to, ok := c.fxRates[toCurrency]
+	if !ok || to <= 0 {
+		return Money{}, fmt.Errorf("tedo: no exchange rate for %q", toCurrency)
+	}
+
+	exact := float64(m.Amount) / from * to
+	var amount float64
+	switch c.fxRounding {
+	case "", RoundingHalfUp:
+		amount = math.Round(exact)
+	case RoundingHalfEven:
+		amount = math.RoundToEven(exact)
+	default:
+		return Money{}, fmt.Errorf("tedo: unknown rounding mode %q", c.fxRounding)
+	}
+	return Money{Amount: int(amount), Currency: toCurrency}, nil
+}
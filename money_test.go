@@ -0,0 +1,43 @@
+package tedo
+
+import "testing"
+
+func TestConvertMoneyRounding(t *testing.T) {
+	// 1.5 USD per EUR keeps the halves exact in floating point.
+	c := NewClient("tedo_test_key").WithFXRates(map[string]float64{"EUR": 1, "USD": 1.5})
+
+	tests := []struct {
+		cents    int
+		halfUp   int
+		halfEven int
+	}{
+		{0, 0, 0},
+		{1, 2, 2},    // 1.5
+		{3, 5, 4},    // 4.5
+		{5, 8, 8},    // 7.5
+		{-1, -2, -2}, // -1.5
+		{-3, -5, -4}, // -4.5
+		{2, 3, 3},    // exact
+	}
+	for _, tt := range tests {
+		for mode, want := range map[string]int{"": tt.halfUp, RoundingHalfUp: tt.halfUp, RoundingHalfEven: tt.halfEven} {
+			got, err := c.WithFXRounding(mode).ConvertMoney(Money{Amount: tt.cents, Currency: "EUR"}, "USD")
+			if err != nil {
+				t.Fatalf("ConvertMoney(%d, mode %q): %v", tt.cents, mode, err)
+			}
+			if got != (Money{Amount: want, Currency: "USD"}) {
+				t.Errorf("ConvertMoney(%d, mode %q) = %+v, want %d USD", tt.cents, mode, got, want)
+			}
+		}
+	}
+
+	if _, err := c.WithFXRounding("banker").ConvertMoney(Money{Amount: 1, Currency: "EUR"}, "USD"); err == nil {
+		t.Errorf("ConvertMoney with an unknown rounding mode succeeded")
+	}
+	if got, err := c.WithFXRounding("banker").ConvertMoney(Money{Amount: 1, Currency: "EUR"}, "EUR"); err != nil || got.Amount != 1 {
+		t.Errorf("same-currency ConvertMoney = %+v, %v; want it unchanged", got, err)
+	}
+	if _, err := c.ConvertMoney(Money{Amount: 1, Currency: "EUR"}, "GBP"); err == nil {
+		t.Errorf("ConvertMoney to a currency without a rate succeeded")
+	}
+}
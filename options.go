@@ -0,0 +1,191 @@
+package tedo
+
+import (
+	"log/slog"
+	"maps"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// ClientOption configures a Client at construction; see NewClient. The
+// chained With* methods on Client apply the same options to an existing
+// client.
+type ClientOption func(*Client)
+
+// WithBaseURL sets a custom base URL (useful for testing).
+func WithBaseURL(url string) ClientOption {
+	return func(c *Client) {
+		c.baseURL = url
+	}
+}
+
+// WithHTTPClient sets a custom HTTP client.
+func WithHTTPClient(httpClient *http.Client) ClientOption {
+	return func(c *Client) {
+		c.httpClient = httpClient
+	}
+}
+
+// WithHTTPTimeout sets the overall timeout of the HTTP client, which defaults
+// to 30 seconds. Apply it after WithHTTPClient; a caller-supplied client is
+// copied rather than modified.
+func WithHTTPTimeout(d time.Duration) ClientOption {
+	return func(c *Client) {
+		httpClient := *c.httpClient
+		httpClient.Timeout = d
+		c.httpClient = &httpClient
+	}
+}
+
+// WithMaxRetries enables automatic retries; see Client.WithMaxRetries.
+func WithMaxRetries(n int) ClientOption {
+	return func(c *Client) {
+		c.maxRetries = n
+	}
+}
+
+// WithMaxIntervalCount sets the largest IntervalCount CreatePrice accepts
+// for interval, e.g. to follow a change in the API's limits. The defaults
+// are 12 for "month" and 3 for "year"; other intervals are unlimited.
+func WithMaxIntervalCount(interval string, n int) ClientOption {
+	return func(c *Client) {
+		limits := maps.Clone(c.maxIntervalCount)
+		if limits == nil {
+			limits = map[string]int{}
+		}
+		limits[interval] = n
+		c.maxIntervalCount = limits
+	}
+}
+
+// WithLogger enables request logging; see Client.WithLogger.
+func WithLogger(logger *slog.Logger) ClientOption {
+	return func(c *Client) {
+		c.logger = logger
+	}
+}
+
+// RequestOption customizes a single API call. Every service method accepts
+// options as trailing arguments, and they compose in order.
+type RequestOption func(*requestOptions)
+
+// requestOptions is the per-call configuration built from RequestOptions.
+type requestOptions struct {
+	idempotencyKey string
+	headers        http.Header
+	query          url.Values
+	timeout        time.Duration
+	meta           *ResponseMeta
+	concurrency    int
+	keepGoing      bool // continue iterating past errors; see WithStopOnError
+	readOnly       bool // a POST that changes nothing; see readOnly
+}
+
+// Idempotent sends key in the Idempotency-Key header of the call, so the API
+// applies a repeated create at most once. The same key is reused on every
+// retry of the call. It takes precedence over a key set with
+// WithIdempotencyKey.
+func Idempotent(key string) RequestOption {
+	return func(o *requestOptions) {
+		o.idempotencyKey = key
+	}
+}
+
+// WithHeader sets a header on the call, e.g. a correlation ID. It is applied
+// after the client's own headers.
+func WithHeader(key, value string) RequestOption {
+	return func(o *requestOptions) {
+		if o.headers == nil {
+			o.headers = http.Header{}
+		}
+		o.headers.Set(key, value)
+	}
+}
+
+// WithQuery adds a query parameter to the call's URL.
+func WithQuery(key, value string) RequestOption {
+	return func(o *requestOptions) {
+		if o.query == nil {
+			o.query = url.Values{}
+		}
+		o.query.Add(key, value)
+	}
+}
+
+// WithTimeout bounds the call, including any retries, by d, overriding
+// Client.WithDefaultTimeout. It derives a context deadline and leaves the
+// shared HTTP client untouched; an earlier deadline on the caller's context
+// still applies.
+func WithTimeout(d time.Duration) RequestOption {
+	return func(o *requestOptions) {
+		o.timeout = d
+	}
+}
+
+// ResponseMeta is metadata about the response to a call, filled in by
+// WithResponseMetadata. When a call is retried it describes the final
+// attempt.
+type ResponseMeta struct {
+	StatusCode int           // zero if no response was received
+	Header     http.Header   // response headers, e.g. deprecation warnings
+	RequestID  string        // X-Request-Id header, empty if absent
+	Duration   time.Duration // of the final attempt, including reading the body
+}
+
+func (m *ResponseMeta) fill(resp *apiResponse, duration time.Duration) {
+	*m = ResponseMeta{
+		StatusCode: resp.status,
+		Header:     resp.header,
+		RequestID:  resp.header.Get("X-Request-Id"),
+		Duration:   duration,
+	}
+}
+
+// WithResponseMetadata fills meta from the call's final response, whether the
+// call succeeds or fails. Every service method accepts it.
+func WithResponseMetadata(meta *ResponseMeta) RequestOption {
+	return func(o *requestOptions) {
+		o.meta = meta
+	}
+}
+
+// WithConcurrency sets how many requests a batch method such as
+// CheckEntitlementForCustomers runs in parallel. Other methods ignore it.
+func WithConcurrency(n int) RequestOption {
+	return func(o *requestOptions) {
+		o.concurrency = n
+	}
+}
+
+// WithStopOnError sets whether an iterator stops at the first error, which
+// is the default. With false, iterators continue past errors that leave the
+// rest of the listing reachable, such as a plan whose entitlements fail to
+// load in ListAllEntitlements, yielding each error with a nil value and
+// carrying on.
+//
+// Errors that cut off the rest of the listing stop every iterator in either
+// mode: a failed page fetch (the next page's cursor comes with it), a
+// pagination loop and cancellation of ctx. The customer and subscription
+// iterators only fail in these ways, so they behave the same in both modes.
+// Continuing drops the failed part of the results, so callers that opt in
+// must still check every error. Methods that don't iterate ignore it.
+func WithStopOnError(stop bool) RequestOption {
+	return func(o *requestOptions) {
+		o.keepGoing = !stop
+	}
+}
+
+// readOnly marks a POST that changes nothing, such as an entitlement check,
+// so that dry-run mode still sends it and it is retried like a GET.
+func readOnly(o *requestOptions) {
+	o.readOnly = true
+}
+
+func newRequestOptions(opts []RequestOption) *requestOptions {
+	o := &requestOptions{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
@@ -0,0 +1,110 @@
+package tedo
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// pathf expands the {name} placeholders in template with the given
+// values, in order, escaping each one as a single path segment. An empty
+// or blank value is rejected with a validation error naming the
+// placeholder, so a missing ID never falls through to the collection
+// endpoint, and so are "." and "..", which would resolve to a different
+// path.
+func pathf(template string, values ...string) (string, error) {
+	var b strings.Builder
+	rest := template
+	for _, value := range values {
+		start := strings.IndexByte(rest, '{')
+		end := strings.IndexByte(rest, '}')
+		if start < 0 || end < start {
+			panic("tedo: too many values for path " + template)
+		}
+		name := rest[start+1 : end]
+		switch {
+		case strings.TrimSpace(value) == "":
+			return "", fmt.Errorf("%w: %s is required", ErrValidation, name)
+		case value == "." || value == "..":
+			return "", fmt.Errorf("%w: invalid %s %q", ErrValidation, name, value)
+		}
+		b.WriteString(rest[:start])
+		b.WriteString(url.PathEscape(value))
+		rest = rest[end+1:]
+	}
+	if strings.IndexByte(rest, '{') >= 0 {
+		panic("tedo: missing values for path " + template)
+	}
+	b.WriteString(rest)
+	return b.String(), nil
+}
+
+// queryParams accumulates the query string of a request. Empty values are
+// skipped, so optional filters that were not set are not sent.
+type queryParams url.Values
+
+func (q queryParams) set(key, value string) {
+	if value != "" {
+		url.Values(q).Set(key, value)
+	}
+}
+
+func (q queryParams) setInt(key string, value int) {
+	if value > 0 {
+		q.set(key, strconv.Itoa(value))
+	}
+}
+
+func (q queryParams) setBool(key string, value *bool) {
+	if value != nil {
+		q.set(key, strconv.FormatBool(*value))
+	}
+}
+
+// setTime sends value in UTC as RFC 3339, skipping the zero time.
+func (q queryParams) setTime(key string, value time.Time) {
+	if !value.IsZero() {
+		q.set(key, value.UTC().Format(time.RFC3339Nano))
+	}
+}
+
+// path appends the encoded query to base, leaving base unchanged when no
+// parameters were set.
+func (q queryParams) path(base string) string {
+	if len(q) == 0 {
+		return base
+	}
+	return base + "?" + url.Values(q).Encode()
+}
+
+// ErrPaginationLoop is returned when paging through a list would never end
+// because the API returned a next cursor it had returned before.
+var ErrPaginationLoop = errors.New("tedo: pagination cursor repeated")
+
+// cursorGuard remembers the cursors of a paginated listing to detect loops.
+type cursorGuard map[string]bool
+
+// newCursorGuard returns a guard for a listing that starts at cursor.
+func newCursorGuard(start string) cursorGuard {
+	g := cursorGuard{}
+	if start != "" {
+		g[start] = true
+	}
+	return g
+}
+
+// next records the next cursor returned by the API, failing if it was seen
+// before. The empty cursor that ends a listing is always accepted.
+func (g cursorGuard) next(cursor string) error {
+	if cursor == "" {
+		return nil
+	}
+	if g[cursor] {
+		return fmt.Errorf("%w: %q", ErrPaginationLoop, cursor)
+	}
+	g[cursor] = true
+	return nil
+}
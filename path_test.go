@@ -0,0 +1,117 @@
+package tedo
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPathfHostileIDs(t *testing.T) {
+	tests := []struct {
+		id      string
+		want    string
+		invalid bool
+	}{
+		{id: "cus_1", want: "/customers/cus_1/usage"},
+		{id: "a/b", want: "/customers/a%2Fb/usage"},
+		{id: "../plans", want: "/customers/..%2Fplans/usage"},
+		{id: "a?b=c", want: "/customers/a%3Fb=c/usage"},
+		{id: "a#b", want: "/customers/a%23b/usage"},
+		{id: "a b", want: "/customers/a%20b/usage"},
+		{id: "a%2Fb", want: "/customers/a%252Fb/usage"},
+		{id: "", invalid: true},
+		{id: " ", invalid: true},
+		{id: "\t\n", invalid: true},
+		{id: ".", invalid: true},
+		{id: "..", invalid: true},
+	}
+	for _, tt := range tests {
+		got, err := pathf("/customers/{customerID}/usage", tt.id)
+		if tt.invalid {
+			if !errors.Is(err, ErrValidation) {
+				t.Errorf("pathf(%q) error = %v, want ErrValidation", tt.id, err)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("pathf(%q) = %q, %v; want %q", tt.id, got, err, tt.want)
+		}
+	}
+}
+
+func TestPathfNamesMissingPlaceholder(t *testing.T) {
+	_, err := pathf("/plans/{planID}/prices/{priceID}", "plan_1", "")
+	if err == nil || err.Error() != "tedo: validation error: priceID is required" {
+		t.Errorf("err = %v, want priceID is required", err)
+	}
+}
+
+func TestQueryParamsEncoding(t *testing.T) {
+	active := true
+	tests := []struct {
+		name  string
+		build func(q queryParams)
+		want  string
+	}{
+		{"empty", func(q queryParams) {}, "/plans"},
+		{"zero values", func(q queryParams) {
+			q.set("key", "")
+			q.setInt("limit", 0)
+			q.setBool("is_active", nil)
+			q.setTime("since", time.Time{})
+		}, "/plans"},
+		{"reserved characters", func(q queryParams) {
+			q.set("key", "pro & team")
+			q.set("cursor", "a+b/c=d?e#f")
+			q.set("email", "jane+test@example.com")
+		}, "/plans?cursor=a%2Bb%2Fc%3Dd%3Fe%23f&email=jane%2Btest%40example.com&key=pro+%26+team"},
+		{"typed values", func(q queryParams) {
+			q.setInt("limit", 50)
+			q.setBool("is_active", &active)
+			q.setTime("since", time.Date(2026, 1, 2, 4, 4, 5, 0, time.FixedZone("CET", 3600)))
+		}, "/plans?is_active=true&limit=50&since=2026-01-02T03%3A04%3A05Z"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q := queryParams{}
+			tt.build(q)
+			got := q.path("/plans")
+			if got != tt.want {
+				t.Errorf("path = %q, want %q", got, tt.want)
+			}
+			if _, err := url.ParseRequestURI(got); err != nil {
+				t.Errorf("path %q doesn't parse: %v", got, err)
+			}
+		})
+	}
+}
+
+func TestQueryRoundTrip(t *testing.T) {
+	var got url.Values
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		got = r.URL.Query()
+		if r.URL.RawQuery == "" && strings.Contains(r.RequestURI, "?") {
+			t.Errorf("request URI %q has an empty query", r.RequestURI)
+		}
+		w.Write([]byte(`{"subscriptions":[]}`))
+	})
+
+	params := &ListSubscriptionsParams{PlanKey: "pro & team", Cursor: "a+b/c=="}
+	if _, err := c.Billing.ListSubscriptions(context.Background(), params); err != nil {
+		t.Fatalf("ListSubscriptions: %v", err)
+	}
+	if got.Get("plan_key") != params.PlanKey || got.Get("cursor") != params.Cursor {
+		t.Errorf("server saw %v, want the values unchanged", got)
+	}
+
+	if _, err := c.Billing.ListSubscriptions(context.Background(), &ListSubscriptionsParams{}); err != nil {
+		t.Fatalf("ListSubscriptions: %v", err)
+	}
+	if len(got) != 0 {
+		t.Errorf("empty params sent %v", got)
+	}
+}
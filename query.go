@@ -0,0 +1,137 @@
+package tedo
+
+import (
+	"slices"
+	"time"
+)
+
+// CustomerQuery builds ListCustomersParams fluently, for queries with
+// several filters:
+//
+//	params := tedo.NewCustomerQuery().
+//		Email("jane@example.com").
+//		UpdatedSince(lastSync).
+//		Limit(50).
+//		Expand("subscriptions").
+//		Params()
+//
+// Each method returns a modified copy, so a partial query can be shared as
+// the base of several others. For simple cases, fill in
+// ListCustomersParams directly.
+type CustomerQuery struct {
+	params ListCustomersParams
+}
+
+// NewCustomerQuery returns an empty customer query, which matches every
+// customer.
+func NewCustomerQuery() CustomerQuery {
+	return CustomerQuery{}
+}
+
+// Email keeps customers with the given email address.
+func (q CustomerQuery) Email(email string) CustomerQuery {
+	q.params.Email = email
+	return q
+}
+
+// ExternalID keeps customers with the given external ID.
+func (q CustomerQuery) ExternalID(externalID string) CustomerQuery {
+	q.params.ExternalID = externalID
+	return q
+}
+
+// UpdatedSince keeps customers updated at or after t.
+func (q CustomerQuery) UpdatedSince(t time.Time) CustomerQuery {
+	q.params.UpdatedSince = t
+	return q
+}
+
+// Expand adds related objects to include with each customer, e.g.
+// "subscriptions".
+func (q CustomerQuery) Expand(fields ...string) CustomerQuery {
+	q.params.Expand = append(slices.Clip(q.params.Expand), fields...)
+	return q
+}
+
+// Limit sets the page size.
+func (q CustomerQuery) Limit(n int) CustomerQuery {
+	q.params.Limit = n
+	return q
+}
+
+// Cursor starts the listing at a cursor from a previous page.
+func (q CustomerQuery) Cursor(cursor string) CustomerQuery {
+	q.params.Cursor = cursor
+	return q
+}
+
+// Params returns the built parameters, for ListCustomers,
+// ListCustomersIter or ListCustomersSeq.
+func (q CustomerQuery) Params() *ListCustomersParams {
+	params := q.params
+	params.Expand = slices.Clone(q.params.Expand)
+	return &params
+}
+
+// SubscriptionQuery builds ListSubscriptionsParams fluently; it is used
+// like CustomerQuery:
+//
+//	params := tedo.NewSubscriptionQuery().
+//		Plan("pro").
+//		Status(tedo.SubscriptionStatusActive).
+//		PeriodEndBetween(start, end).
+//		Params()
+type SubscriptionQuery struct {
+	params ListSubscriptionsParams
+}
+
+// NewSubscriptionQuery returns an empty subscription query, which matches
+// every subscription.
+func NewSubscriptionQuery() SubscriptionQuery {
+	return SubscriptionQuery{}
+}
+
+// Customer keeps the subscriptions of one customer.
+func (q SubscriptionQuery) Customer(customerID string) SubscriptionQuery {
+	q.params.CustomerID = customerID
+	return q
+}
+
+// Status keeps subscriptions in the given status.
+func (q SubscriptionQuery) Status(status SubscriptionStatus) SubscriptionQuery {
+	q.params.Status = status
+	return q
+}
+
+// Plan keeps subscriptions on the plan with the given key.
+func (q SubscriptionQuery) Plan(planKey string) SubscriptionQuery {
+	q.params.PlanKey = planKey
+	return q
+}
+
+// PeriodEndBetween keeps subscriptions whose current period ends in
+// [start, end). A zero bound leaves that side open.
+func (q SubscriptionQuery) PeriodEndBetween(start, end time.Time) SubscriptionQuery {
+	q.params.PeriodEndAfter = start
+	q.params.PeriodEndBefore = end
+	return q
+}
+
+// Limit sets the page size.
+func (q SubscriptionQuery) Limit(n int) SubscriptionQuery {
+	q.params.Limit = n
+	return q
+}
+
+// Cursor starts the listing at a cursor from a previous page.
+func (q SubscriptionQuery) Cursor(cursor string) SubscriptionQuery {
+	q.params.Cursor = cursor
+	return q
+}
+
+// Params returns the built parameters, for ListSubscriptions,
+// ListSubscriptionsIter or ListSubscriptionsSeq.
+func (q SubscriptionQuery) Params() *ListSubscriptionsParams {
+	params := q.params
+	return &params
+}
@@ -0,0 +1,65 @@
+package tedo
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestCustomerQuery(t *testing.T) {
+	since := time.Date(2026, 5, 1, 9, 30, 0, 0, time.FixedZone("CEST", 2*3600))
+	base := NewCustomerQuery().UpdatedSince(since).Expand("subscriptions")
+	jane := base.Email("jane@example.com").Limit(50).Params()
+	other := base.Expand("payment_methods").Params()
+
+	want := &ListCustomersParams{Email: "jane@example.com", UpdatedSince: since, Limit: 50, Expand: []string{"subscriptions"}}
+	if !reflect.DeepEqual(jane, want) {
+		t.Errorf("Params() = %+v, want %+v", jane, want)
+	}
+	if !reflect.DeepEqual(other.Expand, []string{"subscriptions", "payment_methods"}) || other.Email != "" {
+		t.Errorf("query derived from the base = %+v, want only its own changes", other)
+	}
+
+	var query url.Values
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		query = r.URL.Query()
+		w.Write([]byte(`{"customers":[]}`))
+	})
+	if _, err := c.Billing.ListCustomers(context.Background(), other); err != nil {
+		t.Fatalf("ListCustomers: %v", err)
+	}
+	if got := query.Get("updated_since"); got != "2026-05-01T07:30:00Z" {
+		t.Errorf("updated_since = %q, want UTC", got)
+	}
+	if got := query.Get("expand"); got != "subscriptions,payment_methods" {
+		t.Errorf("expand = %q", got)
+	}
+}
+
+func TestSubscriptionQuery(t *testing.T) {
+	start := time.Date(2026, 11, 1, 0, 0, 0, 0, time.UTC)
+	got := NewSubscriptionQuery().
+		Customer("cus_1").
+		Status(SubscriptionStatusActive).
+		Plan("pro").
+		PeriodEndBetween(start, start.AddDate(0, 1, 0)).
+		Limit(10).
+		Cursor("c1").
+		Params()
+
+	want := &ListSubscriptionsParams{
+		CustomerID:      "cus_1",
+		Status:          SubscriptionStatusActive,
+		PlanKey:         "pro",
+		PeriodEndAfter:  start,
+		PeriodEndBefore: start.AddDate(0, 1, 0),
+		Limit:           10,
+		Cursor:          "c1",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Params() = %+v, want %+v", got, want)
+	}
+}
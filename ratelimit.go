@@ -0,0 +1,59 @@
+package tedo
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// RateLimit is the API rate limit state reported in response headers.
+type RateLimit struct {
+	Limit     int       // requests allowed per window
+	Remaining int       // requests left in the current window
+	Reset     time.Time // when the window resets; zero if not reported
+}
+
+// LastRateLimit returns the rate limit state from the most recent response
+// that reported one, or nil if none has. It is safe for concurrent use.
+func (c *Client) LastRateLimit() *RateLimit {
+	rl := c.lastRateLimit.Load()
+	if rl == nil {
+		return nil
+	}
+	copied := *rl
+	return &copied
+}
+
+// rateLimitWait returns how long to hold back new requests: until the rate
+// limit window resets, capped by maxRetryAfter, when the last response
+// reported no requests remaining, and zero otherwise or if no reset time
+// was reported.
+func (c *Client) rateLimitWait() time.Duration {
+	rl := c.lastRateLimit.Load()
+	if rl == nil || rl.Remaining > 0 {
+		return 0
+	}
+	return min(max(time.Until(rl.Reset), 0), c.maxRetryAfter)
+}
+
+// parseRateLimit reads the X-RateLimit-* headers. It returns nil when neither
+// the limit nor the remaining count is present; malformed values are zero.
+func parseRateLimit(h http.Header) *RateLimit {
+	limit := h.Get("X-RateLimit-Limit")
+	remaining := h.Get("X-RateLimit-Remaining")
+	if limit == "" && remaining == "" {
+		return nil
+	}
+
+	rl := &RateLimit{}
+	rl.Limit, _ = strconv.Atoi(limit)
+	rl.Remaining, _ = strconv.Atoi(remaining)
+	if reset := h.Get("X-RateLimit-Reset"); reset != "" {
+		if seconds, err := strconv.ParseInt(reset, 10, 64); err == nil {
+			rl.Reset = time.Unix(seconds, 0)
+		} else if t, err := time.Parse(time.RFC3339, reset); err == nil {
+			rl.Reset = t
+		}
+	}
+	return rl
+}
@@ -0,0 +1,101 @@
+package tedo
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	retryBaseDelay = 500 * time.Millisecond
+	retryMaxDelay  = 8 * time.Second
+)
+
+// isIdempotent reports whether a request may be safely repeated.
+func isIdempotent(method, idempotencyKey string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodDelete:
+		return true
+	}
+	return idempotencyKey != ""
+}
+
+// shouldRetry reports whether a failed attempt is worth retrying (see
+// IsRetryable), unless ctx has ended.
+func shouldRetry(ctx context.Context, err error) bool {
+	return ctx.Err() == nil && IsRetryable(err)
+}
+
+// backoff returns the delay before the retry following the given attempt:
+// exponential from retryBaseDelay, capped at retryMaxDelay, with the upper
+// half jittered so that concurrent clients spread out.
+func backoff(attempt int) time.Duration {
+	d := retryMaxDelay
+	if shift := attempt - 1; shift < 5 {
+		d = min(retryBaseDelay<<shift, retryMaxDelay)
+	}
+	return d/2 + time.Duration(rand.Int63n(int64(d/2)+1))
+}
+
+// retryDelay returns how long to wait after a failed attempt, honoring the
+// API's Retry-After up to the client's cap.
+func (c *Client) retryDelay(err error, attempt int) time.Duration {
+	if apiErr, ok := AsError(err); ok && apiErr.RetryAfter > 0 {
+		return min(apiErr.RetryAfter, c.maxRetryAfter)
+	}
+	return backoff(attempt)
+}
+
+// parseRetryAfter parses a Retry-After header given either as delay seconds
+// or as an HTTP date relative to now. Missing, malformed and past values
+// yield zero.
+func parseRetryAfter(value string, now time.Time) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds <= 0 {
+			return 0
+		}
+		return time.Duration(seconds) * time.Second
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		if d := date.Sub(now); d > 0 {
+			return d
+		}
+	}
+	return 0
+}
+
+// sleepContext waits for d and reports whether the wait completed. It returns
+// false immediately if ctx would expire before d elapses, rather than
+// sleeping only to fail with a deadline error.
+func sleepContext(ctx context.Context, d time.Duration) bool {
+	if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < d {
+		return false
+	}
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
+
+// withAttempts records how many attempts were made on the final error.
+func withAttempts(err error, attempts int) error {
+	if apiErr, ok := AsError(err); ok {
+		apiErr.Attempts = attempts
+		return err
+	}
+	if attempts > 1 {
+		return fmt.Errorf("after %d attempts: %w", attempts, err)
+	}
+	return err
+}
@@ -0,0 +1,213 @@
+package tedo
+
+import (
+	"context"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+)
+
+type timeoutError struct{}
+
+func (timeoutError) Error() string   { return "i/o timeout" }
+func (timeoutError) Timeout() bool   { return true }
+func (timeoutError) Temporary() bool { return true }
+
+func TestIsRetryable(t *testing.T) {
+	opErr := &net.OpError{Op: "dial", Net: "tcp", Err: syscall.ECONNREFUSED}
+	urlErr := func(err error) error {
+		return fmt.Errorf("do request: %w", &url.Error{Op: "Post", URL: "https://api.tedo.ai/x", Err: err})
+	}
+
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"nil", nil, false},
+		{"429", &Error{StatusCode: 429}, true},
+		{"500", &Error{StatusCode: 500}, true},
+		{"503 wrapped", fmt.Errorf("get plan: %w", &Error{StatusCode: 503}), true},
+		{"400", &Error{StatusCode: 400}, false},
+		{"404", &Error{StatusCode: 404}, false},
+		{"plain net.OpError", opErr, true},
+		{"net.OpError in url.Error", urlErr(opErr), true},
+		{"timeout in url.Error", urlErr(timeoutError{}), true},
+		{"unexpected EOF", urlErr(io.ErrUnexpectedEOF), true},
+		{"read response unexpected EOF", fmt.Errorf("read response: %w", io.ErrUnexpectedEOF), true},
+		{"unsupported scheme", urlErr(errors.New(`unsupported protocol scheme "ftp"`)), false},
+		{"x509", urlErr(x509.UnknownAuthorityError{}), false},
+		{"create request", fmt.Errorf("%w: %w", errCreateRequest, &url.Error{Op: "parse", URL: "::", Err: opErr}), false},
+		{"canceled", urlErr(context.Canceled), false},
+		{"deadline exceeded", urlErr(context.DeadlineExceeded), false},
+		{"other", errors.New("boom"), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsRetryable(tt.err); got != tt.want {
+				t.Errorf("IsRetryable(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConfigurationErrorsNotRetried(t *testing.T) {
+	for _, baseURL := range []string{"ftp://api.tedo.ai", "http://[::1"} {
+		c := NewClient("tedo_test_key", WithBaseURL(baseURL), WithMaxRetries(3))
+		_, err := c.Billing.GetPlan(context.Background(), "plan_1")
+		if err == nil {
+			t.Fatalf("%s: expected error", baseURL)
+		}
+		if strings.Contains(err.Error(), "attempts") {
+			t.Errorf("%s: error was retried: %v", baseURL, err)
+		}
+	}
+}
+
+func TestRetryRecoversFromTransientFailures(t *testing.T) {
+	var calls atomic.Int32
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) <= 2 {
+			w.Header().Set("Retry-After", "1")
+			http.Error(w, `{"code":"unavailable","message":"try again"}`, http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(`{"id":"plan_1","key":"pro"}`))
+	}).WithMaxRetries(3).WithMaxRetryAfter(time.Millisecond)
+
+	plan, err := c.Billing.GetPlan(context.Background(), "plan_1")
+	if err != nil {
+		t.Fatalf("GetPlan: %v", err)
+	}
+	if plan.ID != "plan_1" {
+		t.Errorf("plan.ID = %q, want plan_1", plan.ID)
+	}
+	if n := calls.Load(); n != 3 {
+		t.Errorf("server saw %d requests, want 3", n)
+	}
+}
+
+func TestRetrySkipsPostWithoutIdempotencyKey(t *testing.T) {
+	var calls atomic.Int32
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.Header().Set("Retry-After", "1")
+		http.Error(w, `{"code":"unavailable","message":"try again"}`, http.StatusServiceUnavailable)
+	}).WithMaxRetries(3).WithMaxRetryAfter(time.Millisecond)
+
+	_, err := c.Billing.CreatePlan(context.Background(), &CreatePlanParams{Key: "pro", Name: "Pro"})
+	if !IsServerError(err) {
+		t.Fatalf("CreatePlan error = %v, want 503", err)
+	}
+	if n := calls.Load(); n != 1 {
+		t.Errorf("server saw %d requests, want 1", n)
+	}
+
+	calls.Store(0)
+	_, err = c.Billing.CreatePlan(context.Background(), &CreatePlanParams{Key: "pro", Name: "Pro"}, Idempotent("plan-pro"))
+	if !IsServerError(err) {
+		t.Fatalf("CreatePlan error = %v, want 503", err)
+	}
+	if n := calls.Load(); n != 4 {
+		t.Errorf("with idempotency key, server saw %d requests, want 4", n)
+	}
+}
+
+func TestRetryGivesUpBeforeDeadline(t *testing.T) {
+	var calls atomic.Int32
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.Header().Set("Retry-After", "2")
+		http.Error(w, `{"code":"unavailable","message":"try again"}`, http.StatusServiceUnavailable)
+	}).WithMaxRetries(5)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, err := c.Billing.GetPlan(ctx, "plan_1")
+	if elapsed := time.Since(start); elapsed > 150*time.Millisecond {
+		t.Errorf("GetPlan took %v, want it to fail without waiting out the deadline", elapsed)
+	}
+	if !IsServerError(err) {
+		t.Errorf("GetPlan error = %v, want the 503 rather than a deadline error", err)
+	}
+	if n := calls.Load(); n != 1 {
+		t.Errorf("server saw %d requests, want 1", n)
+	}
+}
+
+func TestSleepContext(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Hour)
+	defer cancel()
+	if !sleepContext(ctx, time.Millisecond) {
+		t.Errorf("sleepContext returned false for a wait well within the deadline")
+	}
+
+	short, cancelShort := context.WithTimeout(context.Background(), time.Second)
+	defer cancelShort()
+	start := time.Now()
+	if sleepContext(short, time.Minute) {
+		t.Errorf("sleepContext returned true for a wait past the deadline")
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("sleepContext waited %v before giving up, want an immediate return", elapsed)
+	}
+
+	canceled, cancelNow := context.WithCancel(context.Background())
+	cancelNow()
+	if sleepContext(canceled, time.Minute) {
+		t.Errorf("sleepContext returned true on a canceled context")
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name  string
+		value string
+		want  time.Duration
+	}{
+		{"missing", "", 0},
+		{"seconds", "120", 2 * time.Minute},
+		{"zero seconds", "0", 0},
+		{"negative seconds", "-5", 0},
+		{"HTTP date", now.Add(90 * time.Second).Format(http.TimeFormat), 90 * time.Second},
+		{"RFC 850 date", now.Add(time.Minute).Format("Monday, 02-Jan-06 15:04:05 GMT"), time.Minute},
+		{"past date", now.Add(-time.Minute).Format(http.TimeFormat), 0},
+		{"garbage", "soon", 0},
+		{"fractional seconds", "1.5", 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parseRetryAfter(tt.value, now); got != tt.want {
+				t.Errorf("parseRetryAfter(%q) = %v, want %v", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRetryAfterOnError(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", r.URL.Query().Get("retry_after"))
+		http.Error(w, `{"code":"rate_limited","message":"slow down"}`, http.StatusTooManyRequests)
+	})
+	for value, want := range map[string]time.Duration{"7": 7 * time.Second, "": 0, "later": 0} {
+		_, err := c.Billing.GetPlan(context.Background(), "plan_1", WithQuery("retry_after", value))
+		apiErr, ok := AsError(err)
+		if !ok || !IsRateLimited(err) {
+			t.Fatalf("Retry-After %q: error = %v, want a 429", value, err)
+		}
+		if apiErr.RetryAfter != want {
+			t.Errorf("Retry-After %q: RetryAfter = %v, want %v", value, apiErr.RetryAfter, want)
+		}
+	}
+}
@@ -12,101 +12,591 @@ package tedo
 import (
 	"bytes"
 	"context"
+	"crypto/tls"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"log/slog"
+	"net"
 	"net/http"
+	"net/url"
+	"os"
+	"reflect"
+	"runtime"
+	"slices"
+	"strings"
+	"sync/atomic"
 	"time"
 )
 
+// Version is the version of this SDK, sent in the User-Agent header. Bump it
+// when tagging a release.
+const Version = "0.1.0"
+
+// defaultUserAgent identifies the SDK and Go version, e.g.
+// "tedo-go/0.1.0 go/go1.22.1".
+var defaultUserAgent = "tedo-go/" + Version + " go/" + runtime.Version()
+
 const (
 	defaultBaseURL = "https://api.tedo.ai/v1"
 	defaultTimeout = 30 * time.Second
+
+	defaultMaxRetryAfter = time.Minute
 )
 
 // Client is the Tedo API client.
 type Client struct {
-	apiKey     string
-	baseURL    string
-	httpClient *http.Client
+	apiKey        string
+	baseURL       string
+	httpClient    *http.Client
+	userAgent     string
+	maxRetries    int
+	maxRetryAfter time.Duration
+	callTimeout   time.Duration
+
+	maxIntervalCount map[string]int // see WithMaxIntervalCount
+
+	middlewares   []Middleware
+	callHooks     []CallHook
+	metrics       MetricsObserver
+	debug         *debugWriter
+	logger        *slog.Logger
+	logBodies     bool
+	dryRun        bool
+	fxRates       map[string]float64
+	fxRounding    string
+	lastRateLimit atomic.Pointer[RateLimit]
 
 	// Services
 	Billing *BillingService
 }
 
-// NewClient creates a new Tedo API client.
-func NewClient(apiKey string) *Client {
+// NewClient creates a new Tedo API client. Options are applied in order; the
+// configuration is not validated, see NewClientE.
+func NewClient(apiKey string, opts ...ClientOption) *Client {
 	c := &Client{
-		apiKey:  apiKey,
-		baseURL: defaultBaseURL,
+		apiKey:        apiKey,
+		baseURL:       defaultBaseURL,
+		userAgent:     defaultUserAgent,
+		maxRetryAfter: defaultMaxRetryAfter,
 		httpClient: &http.Client{
 			Timeout: defaultTimeout,
 		},
+		maxIntervalCount: defaultMaxIntervalCount,
 	}
 
 	// Initialize services
 	c.Billing = &BillingService{client: c}
 
+	for _, opt := range opts {
+		opt(c)
+	}
+
 	return c
 }
 
-// WithBaseURL sets a custom base URL (useful for testing).
+// NewClientE is like NewClient but returns an error if the resulting
+// configuration is invalid: an empty API key or a base URL that is not an
+// absolute http(s) URL.
+func NewClientE(apiKey string, opts ...ClientOption) (*Client, error) {
+	c := NewClient(apiKey, opts...)
+	if err := c.validate(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+func (c *Client) validate() error {
+	if c.apiKey == "" {
+		return errors.New("tedo: API key is required")
+	}
+	u, err := url.Parse(c.baseURL)
+	if err != nil {
+		return fmt.Errorf("tedo: invalid base URL: %w", err)
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("tedo: invalid base URL %q: must be an absolute http or https URL", c.baseURL)
+	}
+	return nil
+}
+
+// Environment variables read by NewClientFromEnv.
+const (
+	EnvAPIKey  = "TEDO_API_KEY"
+	EnvBaseURL = "TEDO_BASE_URL"
+)
+
+// NewClientFromEnv creates a new Tedo API client configured from the
+// environment. The API key is read from TEDO_API_KEY, which must be set, and
+// TEDO_BASE_URL optionally overrides the base URL.
+func NewClientFromEnv() (*Client, error) {
+	apiKey := os.Getenv(EnvAPIKey)
+	if apiKey == "" {
+		return nil, fmt.Errorf("tedo: %s is not set", EnvAPIKey)
+	}
+
+	c := NewClient(apiKey)
+	if baseURL := os.Getenv(EnvBaseURL); baseURL != "" {
+		WithBaseURL(baseURL)(c)
+	}
+	return c, nil
+}
+
+// WithBaseURL returns a copy of the client that sends requests to url (useful
+// for testing). The receiver is not modified, so deriving a test client from
+// a shared one leaves the shared one pointed at its original URL.
 func (c *Client) WithBaseURL(url string) *Client {
-	c.baseURL = url
-	return c
+	return c.derive(WithBaseURL(url))
 }
 
-// WithHTTPClient sets a custom HTTP client.
+// WithHTTPClient returns a copy of the client that uses httpClient. The
+// receiver is not modified.
 func (c *Client) WithHTTPClient(httpClient *http.Client) *Client {
-	c.httpClient = httpClient
-	return c
+	return c.derive(WithHTTPClient(httpClient))
+}
+
+// Clone returns a copy of the client with the same configuration and its
+// own services. The copy shares the HTTP client and starts with the
+// original's LastRateLimit.
+//
+// Like Clone, every With method on Client (and Use) returns a modified copy
+// and leaves its receiver untouched, so a shared client can't be
+// reconfigured by accident and derived clients are safe to create
+// concurrently with calls on the original. Assign the result:
+//
+//	client = client.WithMaxRetries(3)
+func (c *Client) Clone() *Client {
+	clone := &Client{
+		apiKey:        c.apiKey,
+		baseURL:       c.baseURL,
+		httpClient:    c.httpClient,
+		userAgent:     c.userAgent,
+		maxRetries:    c.maxRetries,
+		maxRetryAfter: c.maxRetryAfter,
+		callTimeout:   c.callTimeout,
+		middlewares:   slices.Clip(c.middlewares),
+		callHooks:     slices.Clip(c.callHooks),
+		metrics:       c.metrics,
+		debug:         c.debug,
+		logger:        c.logger,
+		logBodies:     c.logBodies,
+		dryRun:        c.dryRun,
+		fxRates:       c.fxRates,
+		fxRounding:    c.fxRounding,
+
+		maxIntervalCount: c.maxIntervalCount,
+	}
+	clone.lastRateLimit.Store(c.lastRateLimit.Load())
+	clone.Billing = &BillingService{client: clone}
+	return clone
+}
+
+// derive returns a clone of the client with opt applied.
+func (c *Client) derive(opt ClientOption) *Client {
+	clone := c.Clone()
+	opt(clone)
+	return clone
+}
+
+type idempotencyKeyContextKey struct{}
+
+// WithIdempotencyKey returns a copy of ctx carrying an idempotency key. Any
+// mutating request (POST, PATCH, PUT, DELETE) made with the returned context
+// sends the key in the Idempotency-Key header, so the API applies it at most
+// once. Keys passed explicitly to a method, such as
+// RecordUsageParams.IdempotencyKey, take precedence.
+func WithIdempotencyKey(ctx context.Context, key string) context.Context {
+	return context.WithValue(ctx, idempotencyKeyContextKey{}, key)
+}
+
+// idempotencyKeyFromContext returns the idempotency key stored in ctx, if any.
+func idempotencyKeyFromContext(ctx context.Context) string {
+	key, _ := ctx.Value(idempotencyKeyContextKey{}).(string)
+	return key
+}
+
+type dryRunContextKey struct{}
+
+// ErrDryRun is returned by mutating calls that were not sent because dry-run
+// mode is on; see WithDryRun.
+var ErrDryRun = errors.New("tedo: dry run, request not sent")
+
+// WithDryRun returns a copy of ctx under which mutating requests (POST, PATCH,
+// PUT, DELETE) are not sent. Such calls fail with ErrDryRun and no result;
+// GET and HEAD requests still go through, as do POSTs that only read, such
+// as CheckEntitlement and PreviewSubscriptionChange. It lets a single call
+// path be exercised against production without changing anything.
+// Suppressed calls are logged with a dry_run attribute when a logger is set
+// (see WithLogger).
+// Client.WithDryRun turns the mode on for every call of a client; either
+// one suppresses a call.
+func WithDryRun(ctx context.Context) context.Context {
+	return context.WithValue(ctx, dryRunContextKey{}, true)
+}
+
+// WithDryRun returns a copy of the client that suppresses every mutating
+// call when enabled, as if each were made under the context WithDryRun.
+func (c *Client) WithDryRun(enabled bool) *Client {
+	return c.derive(func(c *Client) {
+		c.dryRun = enabled
+	})
+}
+
+// isDryRun reports whether a call made with ctx must not be sent.
+func (c *Client) isDryRun(ctx context.Context) bool {
+	dryRun, _ := ctx.Value(dryRunContextKey{}).(bool)
+	return dryRun || c.dryRun
+}
+
+// RawBody is a request body sent as-is with its own content type instead of
+// being JSON-encoded, for endpoints that ingest formats such as text/csv or
+// application/x-ndjson. Pass a *RawBody as the body to Do.
+type RawBody struct {
+	ContentType string
+	Reader      io.Reader
+}
+
+// Do performs an API request against the client's base URL and decodes the
+// JSON response into result. It is an escape hatch for endpoints the client
+// does not model yet: body is JSON-encoded unless it is a *RawBody, and a
+// *json.RawMessage result receives the undecoded response.
+func (c *Client) Do(ctx context.Context, method, path string, body, result any, opts ...RequestOption) error {
+	return c.do(ctx, c.baseURL, method, path, body, result, opts...)
+}
+
+// DoWithBaseURL performs a single API request against baseURL instead of the
+// client's configured base URL, decoding the response into result.
+//
+// This is an advanced escape hatch for migrations and debugging, e.g. routing
+// one call to a staging service while the rest of the client talks to
+// production. Mixing base URLs on one client makes it easy to send data to the
+// wrong environment; prefer a second client for anything long-lived.
+func (c *Client) DoWithBaseURL(ctx context.Context, baseURL, method, path string, body, result any, opts ...RequestOption) error {
+	return c.do(ctx, baseURL, method, path, body, result, opts...)
+}
+
+// WithMaxRetries enables automatic retries of failed requests, up to n
+// retries after the first attempt. Only idempotent requests are retried: GET,
+// DELETE, POSTs that only read such as CheckEntitlement, and mutating
+// requests carrying an idempotency key (see WithIdempotencyKey). Requests
+// are retried on 429 and 5xx responses and on network errors, with
+// exponential backoff and jitter between attempts. Retries are disabled by
+// default.
+func (c *Client) WithMaxRetries(n int) *Client {
+	return c.derive(WithMaxRetries(n))
+}
+
+// WithMaxRetryAfter caps how long a retry waits when the API responds with a
+// Retry-After header. Longer waits are shortened to d. The default is one
+// minute. Waits never extend past the context deadline.
+func (c *Client) WithMaxRetryAfter(d time.Duration) *Client {
+	return c.derive(func(c *Client) {
+		c.maxRetryAfter = d
+	})
+}
+
+// WithDefaultTimeout bounds every call, including any retries, by d unless
+// the call passes its own WithTimeout. The deadline is set on the request
+// context, so an earlier deadline on the caller's context still wins. The
+// HTTP client's own timeout (30 seconds by default, see WithHTTPTimeout)
+// applies to each attempt in addition.
+func (c *Client) WithDefaultTimeout(d time.Duration) *Client {
+	return c.derive(func(c *Client) {
+		c.callTimeout = d
+	})
+}
+
+// WithMaxIntervalCount returns a copy of the client whose CreatePrice
+// accepts IntervalCount up to n for interval; see the WithMaxIntervalCount
+// option.
+func (c *Client) WithMaxIntervalCount(interval string, n int) *Client {
+	return c.derive(WithMaxIntervalCount(interval, n))
+}
+
+// WithUserAgent appends an application identifier to the User-Agent header,
+// e.g. "tedo-go/0.1.0 go/go1.22.1 myapp/1.2", so the app's traffic can be
+// told apart in Tedo's logs.
+func (c *Client) WithUserAgent(appName string) *Client {
+	return c.derive(func(c *Client) {
+		c.userAgent = defaultUserAgent + " " + appName
+	})
+}
+
+// WithAppInfo identifies the integrating application in the User-Agent
+// header as "name/version (url)". version and url are optional.
+func (c *Client) WithAppInfo(name, version, url string) *Client {
+	info := name
+	if version != "" {
+		info += "/" + version
+	}
+	if url != "" {
+		info += " (" + url + ")"
+	}
+	return c.WithUserAgent(info)
+}
+
+// WithHTTP2 enables or disables HTTP/2 for API requests.
+//
+// By default the client uses Go's default transport, which negotiates HTTP/2
+// over TLS via ALPN when the server supports it. Enabling forces an HTTP/2
+// attempt and advertises "h2" explicitly; disabling pins connections to
+// HTTP/1.1 for environments where HTTP/2 causes problems (e.g. some proxies).
+//
+// The setting is applied to a copy of the current transport, so call it after
+// WithHTTPClient. It has no effect when the HTTP client uses a transport that
+// is not an *http.Transport.
+func (c *Client) WithHTTP2(enabled bool) *Client {
+	return c.withTransport(func(t *http.Transport) {
+		if t.TLSClientConfig == nil {
+			t.TLSClientConfig = &tls.Config{}
+		}
+		t.ForceAttemptHTTP2 = enabled
+		if enabled {
+			t.TLSNextProto = nil
+			t.TLSClientConfig.NextProtos = []string{"h2", "http/1.1"}
+		} else {
+			// A non-nil, empty map disables the transport's HTTP/2 support.
+			t.TLSNextProto = map[string]func(string, *tls.Conn) http.RoundTripper{}
+			t.TLSClientConfig.NextProtos = []string{"http/1.1"}
+		}
+	})
+}
+
+// WithDialTimeout limits how long establishing a TCP connection to the API may
+// take, so connection problems fail fast independently of slow responses.
+//
+// Like WithHTTP2, it applies to a copy of the current *http.Transport: call it
+// after WithHTTPClient. It has no effect on other round trippers.
+func (c *Client) WithDialTimeout(d time.Duration) *Client {
+	return c.withTransport(func(t *http.Transport) {
+		t.DialContext = (&net.Dialer{
+			Timeout:   d,
+			KeepAlive: 30 * time.Second,
+		}).DialContext
+	})
+}
+
+// WithResponseHeaderTimeout limits how long to wait for response headers after
+// a request is sent. Reading the body is not limited by it, so large list
+// responses may still stream slowly. The client-wide timeout still applies.
+//
+// It follows the same transport rules as WithDialTimeout.
+func (c *Client) WithResponseHeaderTimeout(d time.Duration) *Client {
+	return c.withTransport(func(t *http.Transport) {
+		t.ResponseHeaderTimeout = d
+	})
+}
+
+// withTransport returns a clone of the client whose transport is configured
+// by fn; see configureTransport.
+func (c *Client) withTransport(fn func(*http.Transport)) *Client {
+	return c.derive(func(c *Client) {
+		c.configureTransport(fn)
+	})
+}
+
+// configureTransport applies fn to a copy of the client's *http.Transport and
+// installs the copy on a copy of the HTTP client, leaving caller-supplied
+// clients and transports untouched. Custom round trippers are left as-is.
+func (c *Client) configureTransport(fn func(*http.Transport)) {
+	var t *http.Transport
+	switch rt := c.httpClient.Transport.(type) {
+	case nil:
+		t = http.DefaultTransport.(*http.Transport).Clone()
+	case *http.Transport:
+		t = rt.Clone()
+	default:
+		return
+	}
+	fn(t)
+
+	httpClient := *c.httpClient
+	httpClient.Transport = t
+	c.httpClient = &httpClient
 }
 
 // request performs an API request and decodes the response.
-func (c *Client) request(ctx context.Context, method, path string, body, result any) error {
-	var bodyReader io.Reader
-	if body != nil {
-		jsonBody, err := json.Marshal(body)
+func (c *Client) request(ctx context.Context, method, path string, body, result any, opts ...RequestOption) error {
+	return c.do(ctx, c.baseURL, method, path, body, result, opts...)
+}
+
+// do performs an API request against baseURL and decodes the response.
+func (c *Client) do(ctx context.Context, baseURL, method, path string, body, result any, opts ...RequestOption) (err error) {
+	start := time.Now()
+	options := newRequestOptions(opts)
+	timeout := options.timeout
+	if timeout <= 0 {
+		timeout = c.callTimeout
+	}
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	var status, attempts int
+	if len(c.callHooks) > 0 {
+		var end func(CallResult)
+		ctx, end = c.startCall(ctx, method, path)
+		defer func() {
+			end(CallResult{StatusCode: status, Attempts: attempts, Err: err})
+		}()
+	}
+	if len(options.query) > 0 {
+		sep := "?"
+		if strings.Contains(path, "?") {
+			sep = "&"
+		}
+		path += sep + options.query.Encode()
+	}
+
+	// A nil body (including a typed nil params pointer) sends no body and no
+	// Content-Type; net/http still sets Content-Length: 0 on POST.
+	var jsonBody []byte
+	var rawReader io.Reader
+	var contentType string
+	if raw, ok := body.(*RawBody); ok {
+		rawReader = raw.Reader
+		contentType = raw.ContentType
+	} else if !isNil(body) {
+		var err error
+		jsonBody, err = json.Marshal(body)
 		if err != nil {
 			return fmt.Errorf("marshal request body: %w", err)
 		}
-		bodyReader = bytes.NewReader(jsonBody)
+		contentType = "application/json"
+	}
+
+	if method != http.MethodGet && method != http.MethodHead && !options.readOnly && c.isDryRun(ctx) {
+		c.logDryRun(ctx, method, path, jsonBody)
+		return ErrDryRun
+	}
+
+	var idempotencyKey string
+	if method != http.MethodGet {
+		idempotencyKey = options.idempotencyKey
+		if idempotencyKey == "" {
+			idempotencyKey = idempotencyKeyFromContext(ctx)
+		}
 	}
+	// Raw bodies are streamed once and can't be replayed.
+	canRetry := rawReader == nil && (options.readOnly || isIdempotent(method, idempotencyKey))
 
-	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, bodyReader)
+	for attempt := 1; ; attempt++ {
+		bodyReader := rawReader
+		if jsonBody != nil {
+			bodyReader = bytes.NewReader(jsonBody)
+		}
+
+		sent := time.Now()
+		resp, err := c.send(ctx, attempt, baseURL, method, path, bodyReader, contentType, idempotencyKey, options.headers)
+		elapsed := time.Since(sent)
+		status, attempts = resp.status, attempt
+		c.observeAttempt(ctx, method, resp.status, elapsed, err)
+		if err != nil && canRetry && attempt <= c.maxRetries && shouldRetry(ctx, err) && sleepContext(ctx, c.retryDelay(err, attempt)) {
+			continue
+		}
+		if options.meta != nil {
+			options.meta.fill(resp, elapsed)
+		}
+		if err != nil {
+			err = withAttempts(err, attempt)
+			c.logRequest(ctx, method, path, jsonBody, resp.status, time.Since(start), attempt, err)
+			return err
+		}
+		c.logRequest(ctx, method, path, jsonBody, resp.status, time.Since(start), attempt, nil)
+
+		// Hand back the exact response bytes when asked for raw JSON
+		if raw, ok := result.(*json.RawMessage); ok {
+			*raw = resp.body
+			return nil
+		}
+
+		// Decode successful response
+		if result != nil && len(resp.body) > 0 {
+			if err := json.Unmarshal(resp.body, result); err != nil {
+				return fmt.Errorf("decode response: %w", err)
+			}
+		}
+
+		return nil
+	}
+}
+
+// apiResponse is the outcome of a single HTTP attempt. The zero value means
+// no response was received.
+type apiResponse struct {
+	status int
+	header http.Header
+	body   []byte
+}
+
+// send performs a single HTTP attempt. It always returns a non-nil
+// *apiResponse, and an *Error for 4xx/5xx responses.
+func (c *Client) send(ctx context.Context, attempt int, baseURL, method, path string, body io.Reader, contentType, idempotencyKey string, headers http.Header) (*apiResponse, error) {
+	req, err := http.NewRequestWithContext(ctx, method, baseURL+path, body)
 	if err != nil {
-		return fmt.Errorf("create request: %w", err)
+		return &apiResponse{}, fmt.Errorf("%w: %w", errCreateRequest, err)
 	}
 
 	req.Header.Set("Authorization", "Bearer "+c.apiKey)
-	req.Header.Set("Content-Type", "application/json")
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
 	req.Header.Set("Accept", "application/json")
+	req.Header.Set("Accept-Encoding", "gzip")
+	req.Header.Set("User-Agent", c.userAgent)
+	if idempotencyKey != "" {
+		req.Header.Set("Idempotency-Key", idempotencyKey)
+	}
+	for key, values := range headers {
+		req.Header[key] = values
+	}
 
-	resp, err := c.httpClient.Do(req)
+	resp, err := c.roundTrip(req, attempt)
 	if err != nil {
-		return fmt.Errorf("do request: %w", err)
+		return &apiResponse{}, fmt.Errorf("do request: %w", err)
 	}
 	defer resp.Body.Close()
 
+	result := &apiResponse{status: resp.StatusCode, header: resp.Header}
+
+	rateLimit := parseRateLimit(resp.Header)
+	if rateLimit != nil {
+		c.lastRateLimit.Store(rateLimit)
+	}
+
 	// Read response body
 	respBody, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return fmt.Errorf("read response: %w", err)
+		return result, fmt.Errorf("read response: %w", err)
 	}
 
 	// Check for errors
 	if resp.StatusCode >= 400 {
-		return parseError(resp.StatusCode, respBody)
-	}
-
-	// Decode successful response
-	if result != nil && len(respBody) > 0 {
-		if err := json.Unmarshal(respBody, result); err != nil {
-			return fmt.Errorf("decode response: %w", err)
+		apiErr := parseError(resp.StatusCode, resp.Header.Get("Content-Type"), respBody)
+		apiErr.RequestID = resp.Header.Get("X-Request-Id")
+		apiErr.RetryAfter = parseRetryAfter(resp.Header.Get("Retry-After"), time.Now())
+		if resp.StatusCode == http.StatusTooManyRequests {
+			apiErr.RateLimit = rateLimit
 		}
+		return result, apiErr
 	}
 
-	return nil
+	result.body = respBody
+	return result, nil
+}
+
+// isNil reports whether v is nil or a nil pointer, map or slice.
+func isNil(v any) bool {
+	if v == nil {
+		return true
+	}
+	switch rv := reflect.ValueOf(v); rv.Kind() {
+	case reflect.Pointer, reflect.Map, reflect.Slice:
+		return rv.IsNil()
+	}
+	return false
 }
 
 // Error types
@@ -117,48 +607,236 @@ type Error struct {
 	Code       string `json:"code"`
 	Message    string `json:"message"`
 	Field      string `json:"field,omitempty"`
+
+	// Errors lists per-field problems for validation errors that report more
+	// than one. Older responses only set Field; use FieldError to handle both.
+	Errors []FieldError `json:"errors,omitempty"`
+
+	// RequestID is the X-Request-Id of the failed response; quote it when
+	// contacting Tedo support.
+	RequestID string `json:"-"`
+
+	// Attempts is the number of requests made, including retries.
+	Attempts int `json:"-"`
+
+	// RetryAfter is how long the API asked clients to wait before retrying,
+	// from the Retry-After header, or zero if it sent none.
+	RetryAfter time.Duration `json:"-"`
+
+	// RateLimit is the rate limit state reported with a 429 response.
+	RateLimit *RateLimit `json:"-"`
 }
 
 func (e *Error) Error() string {
-	if e.Field != "" {
-		return fmt.Sprintf("tedo: %s - %s (field: %s)", e.Code, e.Message, e.Field)
+	msg := fmt.Sprintf("tedo: %s - %s", e.Code, e.Message)
+	if len(e.Errors) > 0 {
+		fields := make([]string, len(e.Errors))
+		for i, fe := range e.Errors {
+			fields[i] = fe.Field + ": " + fe.Message
+		}
+		msg += " (" + strings.Join(fields, "; ") + ")"
+	} else if e.Field != "" {
+		msg += fmt.Sprintf(" (field: %s)", e.Field)
+	}
+	if e.RequestID != "" {
+		msg += fmt.Sprintf(" (request_id: %s)", e.RequestID)
 	}
-	return fmt.Sprintf("tedo: %s - %s", e.Code, e.Message)
+	if e.Attempts > 1 {
+		msg += fmt.Sprintf(" (after %d attempts)", e.Attempts)
+	}
+	return msg
 }
 
-// IsNotFound returns true if the error is a 404 Not Found.
-func IsNotFound(err error) bool {
-	if e, ok := err.(*Error); ok {
+// FieldError is a validation problem with a single request field.
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// FieldError returns the validation problem reported for field, or nil. For
+// responses in the older single-field shape it matches Field and returns the
+// top-level Message.
+func (e *Error) FieldError(field string) *FieldError {
+	for i := range e.Errors {
+		if e.Errors[i].Field == field {
+			return &e.Errors[i]
+		}
+	}
+	if e.Field == field && field != "" {
+		return &FieldError{Field: e.Field, Message: e.Message}
+	}
+	return nil
+}
+
+// Sentinel errors matched by API errors of the corresponding status, so that
+// errors.Is(err, tedo.ErrNotFound) works through any wrapping.
+var (
+	ErrNotFound     = errors.New("tedo: not found")
+	ErrValidation   = errors.New("tedo: validation error")
+	ErrUnauthorized = errors.New("tedo: unauthorized")
+	ErrForbidden    = errors.New("tedo: forbidden")
+	ErrConflict     = errors.New("tedo: conflict")
+	ErrRateLimited  = errors.New("tedo: rate limited")
+
+	// ErrSubscriptionPaused is matched by the conflict RecordUsage returns
+	// for a subscription paused with PauseSubscriptionParams.PauseUsage.
+	ErrSubscriptionPaused = errors.New("tedo: subscription paused")
+
+	// ErrReactivationExpired is matched by the conflict
+	// ReactivateSubscription returns for a canceled subscription whose
+	// ReactivationDeadline has passed.
+	ErrReactivationExpired = errors.New("tedo: reactivation window expired")
+)
+
+// Error codes the API sets on errors that have their own sentinel.
+const (
+	codeSubscriptionPaused  = "subscription_paused"
+	codeReactivationExpired = "reactivation_expired"
+)
+
+// Is reports whether the error matches one of the sentinel errors.
+func (e *Error) Is(target error) bool {
+	switch target {
+	case ErrNotFound:
 		return e.StatusCode == 404
+	case ErrValidation:
+		return e.StatusCode == 400
+	case ErrUnauthorized:
+		return e.StatusCode == 401
+	case ErrForbidden:
+		return e.StatusCode == 403
+	case ErrConflict:
+		return e.StatusCode == 409
+	case ErrRateLimited:
+		return e.StatusCode == 429
+	case ErrSubscriptionPaused:
+		return e.Code == codeSubscriptionPaused
+	case ErrReactivationExpired:
+		return e.Code == codeReactivationExpired
 	}
 	return false
 }
 
+// AsError returns the *Error in err's chain, if any.
+func AsError(err error) (*Error, bool) {
+	var apiErr *Error
+	if errors.As(err, &apiErr) {
+		return apiErr, true
+	}
+	return nil, false
+}
+
+// IsNotFound returns true if the error is a 404 Not Found.
+func IsNotFound(err error) bool {
+	return errors.Is(err, ErrNotFound)
+}
+
 // IsValidationError returns true if the error is a 400 Bad Request.
 func IsValidationError(err error) bool {
-	if e, ok := err.(*Error); ok {
-		return e.StatusCode == 400
-	}
-	return false
+	return errors.Is(err, ErrValidation)
 }
 
 // IsUnauthorized returns true if the error is a 401 Unauthorized.
 func IsUnauthorized(err error) bool {
-	if e, ok := err.(*Error); ok {
-		return e.StatusCode == 401
+	return errors.Is(err, ErrUnauthorized)
+}
+
+// IsForbidden returns true if the error is a 403 Forbidden.
+func IsForbidden(err error) bool {
+	return errors.Is(err, ErrForbidden)
+}
+
+// IsConflict returns true if the error is a 409 Conflict, e.g. a customer
+// that already exists.
+func IsConflict(err error) bool {
+	return errors.Is(err, ErrConflict)
+}
+
+// IsRateLimited returns true if the error is a 429 Too Many Requests.
+func IsRateLimited(err error) bool {
+	return errors.Is(err, ErrRateLimited)
+}
+
+// IsSubscriptionPaused returns true if the error reports that usage was
+// rejected because the subscription is paused with usage paused as well.
+func IsSubscriptionPaused(err error) bool {
+	return errors.Is(err, ErrSubscriptionPaused)
+}
+
+// IsServerError returns true if the error is a 5xx response.
+func IsServerError(err error) bool {
+	apiErr, ok := AsError(err)
+	return ok && apiErr.StatusCode >= 500
+}
+
+// errCreateRequest marks failures to build a request, such as a malformed
+// base URL, which no retry can fix.
+var errCreateRequest = errors.New("create request")
+
+// IsRetryable reports whether err is worth retrying under the client's own
+// retry policy: 429 and 5xx responses, connection failures (*net.OpError),
+// timeouts and truncated responses. Configuration problems such as a
+// malformed base URL, an unsupported scheme or a rejected TLS certificate
+// are not retryable. Use it to requeue failed work consistently with
+// WithMaxRetries.
+func IsRetryable(err error) bool {
+	if err == nil {
+		return false
 	}
-	return false
+	if apiErr, ok := AsError(err); ok {
+		return apiErr.StatusCode == http.StatusTooManyRequests || apiErr.StatusCode >= 500
+	}
+	if errors.Is(err, errCreateRequest) || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	if errors.Is(err, io.ErrUnexpectedEOF) {
+		return true
+	}
+	// *url.Error implements net.Error for every failure of http.Client.Do,
+	// so classify what it wraps instead.
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		err = urlErr.Err
+	}
+	var opErr *net.OpError
+	if errors.As(err, &opErr) {
+		return true
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
 }
 
-func parseError(statusCode int, body []byte) error {
-	var apiErr Error
-	if err := json.Unmarshal(body, &apiErr); err != nil {
-		// If we can't parse the error, create a generic one
-		apiErr = Error{
-			Code:    "unknown_error",
-			Message: string(body),
+// maxErrorBodyLength bounds how much of a non-JSON error body is kept in
+// Error.Message.
+const maxErrorBodyLength = 256
+
+// parseError builds an *Error from a 4xx/5xx response. Bodies that are not
+// a JSON error, such as HTML pages from a proxy, get Code "http_error" and a
+// message with the start of the body, whitespace collapsed.
+func parseError(statusCode int, contentType string, body []byte) *Error {
+	apiErr := &Error{StatusCode: statusCode, Code: "http_error"}
+
+	status := http.StatusText(statusCode)
+	if status == "" {
+		status = fmt.Sprintf("HTTP %d", statusCode)
+	}
+	text := strings.Join(strings.Fields(string(body)), " ")
+	if text == "" {
+		apiErr.Message = status + " (empty response body)"
+		return apiErr
+	}
+
+	if contentType == "" || strings.Contains(contentType, "json") {
+		var decoded Error
+		if err := json.Unmarshal(body, &decoded); err == nil && (decoded.Code != "" || decoded.Message != "") {
+			decoded.StatusCode = statusCode
+			return &decoded
 		}
 	}
-	apiErr.StatusCode = statusCode
-	return &apiErr
+
+	if len(text) > maxErrorBodyLength {
+		text = strings.ToValidUTF8(text[:maxErrorBodyLength], "") + "..."
+	}
+	apiErr.Message = status + ": " + text
+	return apiErr
 }
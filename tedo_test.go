@@ -0,0 +1,747 @@
+package tedo
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+	"unicode/utf8"
+)
+
+// newTestClient returns a client that sends its requests to handler.
+func newTestClient(t *testing.T, handler http.HandlerFunc) *Client {
+	t.Helper()
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+	return NewClient("tedo_test_key", WithBaseURL(srv.URL))
+}
+
+func TestDerivedClientsLeaveOriginalUnchanged(t *testing.T) {
+	var mu sync.Mutex
+	hits := map[string]int{}
+	handler := func(name string) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			mu.Lock()
+			hits[name]++
+			mu.Unlock()
+			w.Write([]byte(`{"id":"plan_1"}`))
+		}
+	}
+	prod := httptest.NewServer(handler("prod"))
+	defer prod.Close()
+	staging := httptest.NewServer(handler("staging"))
+	defer staging.Close()
+
+	original := NewClient("tedo_test_key", WithBaseURL(prod.URL))
+	ctx := context.Background()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			derived := original.WithBaseURL(staging.URL).
+				WithMaxRetries(2).
+				WithMaxRetryAfter(time.Second).
+				WithDefaultTimeout(time.Second).
+				WithUserAgent("test").
+				WithLogger(slog.New(slog.NewTextHandler(io.Discard, nil))).
+				WithLogBodies(true).
+				WithDebug(io.Discard).
+				WithDebugBodyLimit(10).
+				WithCallHook(func(ctx context.Context, _ CallInfo) (context.Context, func(CallResult)) {
+					return ctx, func(CallResult) {}
+				}).
+				Use(func(next RoundTripFunc) RoundTripFunc { return next }).
+				WithFXRates(map[string]float64{"EUR": 1}).
+				WithFXRounding(RoundingHalfEven).
+				WithHTTP2(false).
+				WithDialTimeout(time.Second).
+				WithResponseHeaderTimeout(time.Second)
+			if _, err := derived.Billing.GetPlan(ctx, "plan_1"); err != nil {
+				t.Errorf("derived GetPlan: %v", err)
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			if _, err := original.Billing.GetPlan(ctx, "plan_1"); err != nil {
+				t.Errorf("original GetPlan: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if hits["prod"] != 20 || hits["staging"] != 20 {
+		t.Errorf("hits = %v, want 20 each", hits)
+	}
+	want := NewClient("tedo_test_key", WithBaseURL(prod.URL))
+	if original.baseURL != want.baseURL || original.maxRetries != 0 || original.maxRetryAfter != want.maxRetryAfter ||
+		original.callTimeout != 0 || original.userAgent != want.userAgent || original.logger != nil || original.logBodies ||
+		original.debug != nil || len(original.callHooks) != 0 || len(original.middlewares) != 0 || original.fxRates != nil ||
+		original.httpClient.Transport != nil {
+		t.Errorf("original client was modified: %+v", original)
+	}
+	if original.Billing.client != original {
+		t.Errorf("original's BillingService points at another client")
+	}
+}
+
+func TestDryRun(t *testing.T) {
+	var methods []string
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		methods = append(methods, r.Method)
+		if r.Method == http.MethodGet {
+			w.Write([]byte(`{"id":"plan_1"}`))
+		}
+	})
+	ctx := context.Background()
+
+	for name, tc := range map[string]struct {
+		client *Client
+		ctx    context.Context
+	}{
+		"context": {c, WithDryRun(ctx)},
+		"client":  {c.WithDryRun(true), ctx},
+	} {
+		methods = nil
+		plan, err := tc.client.Billing.CreatePlan(tc.ctx, &CreatePlanParams{Key: "pro", Name: "Pro"})
+		if !errors.Is(err, ErrDryRun) || plan != nil {
+			t.Errorf("%s: CreatePlan = %v, %v; want ErrDryRun", name, plan, err)
+		}
+		if err := tc.client.Billing.DeletePlan(tc.ctx, "plan_1"); !errors.Is(err, ErrDryRun) {
+			t.Errorf("%s: DeletePlan error = %v, want ErrDryRun", name, err)
+		}
+		if _, err := tc.client.Billing.GetPlan(tc.ctx, "plan_1"); err != nil {
+			t.Errorf("%s: GetPlan: %v", name, err)
+		}
+		if err := tc.client.Do(tc.ctx, http.MethodHead, "/billing/v1/plans/plan_1", nil, nil); err != nil {
+			t.Errorf("%s: HEAD: %v", name, err)
+		}
+		if len(methods) != 2 || methods[0] != http.MethodGet || methods[1] != http.MethodHead {
+			t.Errorf("%s: sent %q, want only GET and HEAD", name, methods)
+		}
+
+		methods = nil
+		if _, err := tc.client.Billing.CheckEntitlementByKey(tc.ctx, "cus_1", "seats"); err != nil {
+			t.Errorf("%s: CheckEntitlementByKey: %v", name, err)
+		}
+		if _, err := tc.client.Billing.CheckEntitlementForCustomers(tc.ctx, []string{"cus_1", "cus_2"}, "seats", WithConcurrency(1)); err != nil {
+			t.Errorf("%s: CheckEntitlementForCustomers: %v", name, err)
+		}
+		if _, err := tc.client.Billing.PreviewSubscriptionChange(tc.ctx, "sub_1", &PreviewSubscriptionChangeParams{PlanKey: "team"}); err != nil {
+			t.Errorf("%s: PreviewSubscriptionChange: %v", name, err)
+		}
+		if len(methods) != 4 {
+			t.Errorf("%s: sent %d read-only POSTs, want 4", name, len(methods))
+		}
+	}
+
+	methods = nil
+	if _, err := c.Billing.CreatePlan(ctx, &CreatePlanParams{Key: "pro", Name: "Pro"}); err != nil {
+		t.Errorf("CreatePlan without dry run: %v", err)
+	}
+	if len(methods) != 1 {
+		t.Errorf("original client suppressed a call after WithDryRun(true) was derived from it")
+	}
+}
+
+// transport returns the client's *http.Transport.
+func transport(t *testing.T, c *Client) *http.Transport {
+	t.Helper()
+	tr, ok := c.httpClient.Transport.(*http.Transport)
+	if !ok {
+		t.Fatalf("transport is %T, want *http.Transport", c.httpClient.Transport)
+	}
+	return tr
+}
+
+func TestWithHTTP2(t *testing.T) {
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"id":"plan_1","name":"` + r.Proto + `"}`))
+	}))
+	srv.EnableHTTP2 = true
+	srv.StartTLS()
+	t.Cleanup(srv.Close)
+	callers := srv.Client().Transport.(*http.Transport)
+	base := NewClient("tedo_test_key", WithBaseURL(srv.URL), WithHTTPClient(srv.Client()))
+
+	tests := []struct {
+		enabled   bool
+		wantProto string
+		wantALPN  []string
+	}{
+		{true, "HTTP/2.0", []string{"h2", "http/1.1"}},
+		{false, "HTTP/1.1", []string{"http/1.1"}},
+	}
+	for _, tt := range tests {
+		c := base.WithHTTP2(tt.enabled)
+		tr := transport(t, c)
+		if tr.ForceAttemptHTTP2 != tt.enabled || !slices.Equal(tr.TLSClientConfig.NextProtos, tt.wantALPN) {
+			t.Errorf("WithHTTP2(%v) transport: ForceAttemptHTTP2 = %v, NextProtos = %q", tt.enabled, tr.ForceAttemptHTTP2, tr.TLSClientConfig.NextProtos)
+		}
+		plan, err := c.Billing.GetPlan(context.Background(), "plan_1")
+		if err != nil {
+			t.Fatalf("WithHTTP2(%v): GetPlan: %v", tt.enabled, err)
+		}
+		if plan.Name != tt.wantProto {
+			t.Errorf("WithHTTP2(%v) negotiated %s, want %s", tt.enabled, plan.Name, tt.wantProto)
+		}
+	}
+	if !callers.ForceAttemptHTTP2 || callers.TLSNextProto != nil && len(callers.TLSNextProto) == 0 {
+		t.Errorf("WithHTTP2(false) modified the caller's transport")
+	}
+}
+
+func TestIdempotencyKeyFromContext(t *testing.T) {
+	var keys []string
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		keys = append(keys, r.Method+" "+r.Header.Get("Idempotency-Key"))
+		if r.Method == "POST" && len(keys) == 1 {
+			w.Header().Set("Retry-After", "1")
+			http.Error(w, `{"code":"unavailable","message":"try again"}`, http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(`{"id":"plan_1"}`))
+	}).WithMaxRetries(1).WithMaxRetryAfter(time.Millisecond)
+	ctx := WithIdempotencyKey(context.Background(), "ctx-key")
+
+	if _, err := c.Billing.CreatePlan(ctx, &CreatePlanParams{Key: "pro", Name: "Pro"}); err != nil {
+		t.Fatalf("CreatePlan: %v", err)
+	}
+	if _, err := c.Billing.CreatePlan(ctx, &CreatePlanParams{Key: "pro", Name: "Pro"}, Idempotent("explicit")); err != nil {
+		t.Fatalf("CreatePlan: %v", err)
+	}
+	if _, err := c.Billing.GetPlan(ctx, "plan_1"); err != nil {
+		t.Fatalf("GetPlan: %v", err)
+	}
+
+	want := []string{"POST ctx-key", "POST ctx-key", "POST explicit", "GET "}
+	if !slices.Equal(keys, want) {
+		t.Errorf("requests = %q, want %q", keys, want)
+	}
+}
+
+// userAgent returns the User-Agent header c sends.
+func userAgent(t *testing.T, c *Client) string {
+	t.Helper()
+	var ua string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ua = r.Header.Get("User-Agent")
+		w.Write([]byte(`{}`))
+	}))
+	defer srv.Close()
+	if _, err := c.WithBaseURL(srv.URL).Billing.GetPlan(context.Background(), "plan_1"); err != nil {
+		t.Fatalf("GetPlan: %v", err)
+	}
+	return ua
+}
+
+func TestUserAgent(t *testing.T) {
+	base := NewClient("tedo_test_key")
+	sdk := "tedo-go/" + Version + " go/" + runtime.Version()
+
+	if got := userAgent(t, base); got != sdk {
+		t.Errorf("default User-Agent = %q, want %q", got, sdk)
+	}
+	app := base.WithUserAgent("myapp/1.2")
+	if got := userAgent(t, app); got != sdk+" myapp/1.2" {
+		t.Errorf("User-Agent = %q, want the app appended", got)
+	}
+	if got := userAgent(t, app.WithUserAgent("other/2")); got != sdk+" other/2" {
+		t.Errorf("User-Agent after a second WithUserAgent = %q, want it replaced", got)
+	}
+	if got := userAgent(t, base); got != sdk {
+		t.Errorf("WithUserAgent changed the original client's User-Agent to %q", got)
+	}
+}
+
+func TestWithAppInfo(t *testing.T) {
+	sdk := "tedo-go/" + Version + " go/" + runtime.Version()
+	tests := []struct {
+		name, version, url string
+		want               string
+	}{
+		{"billing-worker", "", "", sdk + " billing-worker"},
+		{"billing-worker", "3.1.0", "", sdk + " billing-worker/3.1.0"},
+		{"billing-worker", "3.1.0", "https://example.com", sdk + " billing-worker/3.1.0 (https://example.com)"},
+		{"billing-worker", "", "https://example.com", sdk + " billing-worker (https://example.com)"},
+	}
+	for _, tt := range tests {
+		c := NewClient("tedo_test_key").WithAppInfo(tt.name, tt.version, tt.url)
+		if got := userAgent(t, c); got != tt.want {
+			t.Errorf("WithAppInfo(%q, %q, %q) User-Agent = %q, want %q", tt.name, tt.version, tt.url, got, tt.want)
+		}
+	}
+}
+
+func TestNilBodyPost(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if cl := r.Header.Get("Content-Length"); cl != "0" || len(r.TransferEncoding) != 0 || len(body) != 0 {
+			t.Errorf("%s: Content-Length %q, Transfer-Encoding %q, body %q; want an empty body with Content-Length 0",
+				r.URL.Path, cl, r.TransferEncoding, body)
+		}
+		if ct := r.Header.Get("Content-Type"); ct != "" {
+			t.Errorf("%s: Content-Type = %q, want none", r.URL.Path, ct)
+		}
+		w.Write([]byte(`{"id":"sub_1","status":"active"}`))
+	})
+	ctx := context.Background()
+
+	sub, err := c.Billing.ResumeSubscription(ctx, "sub_1")
+	if err != nil {
+		t.Fatalf("ResumeSubscription: %v", err)
+	}
+	if sub.ID != "sub_1" || sub.Status != "active" {
+		t.Errorf("decoded %+v, want sub_1 active", sub)
+	}
+
+	var typedNil *CreatePlanParams
+	var got Subscription
+	if err := c.request(ctx, "POST", "/billing/v1/subscriptions/sub_1/resume", typedNil, &got); err != nil {
+		t.Fatalf("request with a typed nil body: %v", err)
+	}
+	if got.ID != "sub_1" {
+		t.Errorf("decoded %+v, want sub_1", got)
+	}
+}
+
+func TestDoRawBody(t *testing.T) {
+	const ndjson = "{\"customer_id\":\"cus_1\",\"quantity\":3}\n{\"customer_id\":\"cus_2\",\"quantity\":1}\n"
+	var gotType, gotBody string
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		gotType = r.Header.Get("Content-Type")
+		b, _ := io.ReadAll(r.Body)
+		gotBody = string(b)
+		w.Write([]byte(`{"imported":2}`))
+	})
+
+	// A MultiReader is not a type net/http knows the length of, so the body
+	// is streamed.
+	body := &RawBody{
+		ContentType: "application/x-ndjson",
+		Reader:      io.MultiReader(strings.NewReader(ndjson[:20]), strings.NewReader(ndjson[20:])),
+	}
+	var result struct{ Imported int }
+	if err := c.Do(context.Background(), http.MethodPost, "/billing/v1/usage/import", body, &result); err != nil {
+		t.Fatalf("Do: %v", err)
+	}
+	if gotType != "application/x-ndjson" {
+		t.Errorf("Content-Type = %q, want application/x-ndjson", gotType)
+	}
+	if gotBody != ndjson {
+		t.Errorf("body = %q, want %q", gotBody, ndjson)
+	}
+	if result.Imported != 2 {
+		t.Errorf("result = %+v, want the decoded JSON response", result)
+	}
+}
+
+func TestTransportTimeouts(t *testing.T) {
+	callers := &http.Transport{MaxIdleConns: 7}
+	base := NewClient("tedo_test_key", WithHTTPClient(&http.Client{Transport: callers}))
+	c := base.WithDialTimeout(2 * time.Second).WithResponseHeaderTimeout(5 * time.Second)
+
+	tr := transport(t, c)
+	if tr.ResponseHeaderTimeout != 5*time.Second {
+		t.Errorf("ResponseHeaderTimeout = %v, want 5s", tr.ResponseHeaderTimeout)
+	}
+	if tr.DialContext == nil {
+		t.Errorf("DialContext not set")
+	}
+	if tr.MaxIdleConns != 7 {
+		t.Errorf("MaxIdleConns = %d, want the caller's settings kept", tr.MaxIdleConns)
+	}
+	if callers.DialContext != nil || callers.ResponseHeaderTimeout != 0 || tr == callers {
+		t.Errorf("the caller's transport was modified")
+	}
+
+	// The defaults are cloned from http.DefaultTransport.
+	tr = transport(t, NewClient("tedo_test_key").WithResponseHeaderTimeout(time.Second))
+	if tr.ResponseHeaderTimeout != time.Second || http.DefaultTransport.(*http.Transport).ResponseHeaderTimeout != 0 {
+		t.Errorf("ResponseHeaderTimeout on a default client = %v", tr.ResponseHeaderTimeout)
+	}
+
+	// Custom round trippers are left alone.
+	rt := roundTripperFunc(func(*http.Request) (*http.Response, error) { return nil, errors.New("unused") })
+	custom := NewClient("tedo_test_key", WithHTTPClient(&http.Client{Transport: rt})).WithDialTimeout(time.Second)
+	if _, ok := custom.httpClient.Transport.(roundTripperFunc); !ok {
+		t.Errorf("transport = %T, want the custom round tripper", custom.httpClient.Transport)
+	}
+}
+
+func TestResponseHeaderTimeout(t *testing.T) {
+	release := make(chan struct{})
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}).WithResponseHeaderTimeout(20 * time.Millisecond)
+	defer close(release)
+
+	_, err := c.Billing.GetPlan(context.Background(), "plan_1")
+	if err == nil || !strings.Contains(err.Error(), "timeout awaiting response headers") {
+		t.Errorf("GetPlan error = %v, want a response header timeout", err)
+	}
+}
+
+// roundTripperFunc adapts a function to http.RoundTripper.
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func TestErrorRequestID(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if id := r.URL.Query().Get("request_id"); id != "" {
+			w.Header().Set("X-Request-Id", id)
+		}
+		http.Error(w, `{"code":"not_found","message":"plan not found"}`, http.StatusNotFound)
+	})
+
+	_, err := c.Billing.GetPlan(context.Background(), "plan_1", WithQuery("request_id", "req_123"))
+	apiErr, ok := AsError(err)
+	if !ok {
+		t.Fatalf("GetPlan error = %v, want *Error", err)
+	}
+	if apiErr.RequestID != "req_123" || !strings.Contains(err.Error(), "(request_id: req_123)") {
+		t.Errorf("error = %q with RequestID %q, want req_123 in both", err, apiErr.RequestID)
+	}
+
+	_, err = c.Billing.GetPlan(context.Background(), "plan_1")
+	apiErr, ok = AsError(err)
+	if !ok {
+		t.Fatalf("GetPlan error = %v, want *Error", err)
+	}
+	if apiErr.RequestID != "" || strings.Contains(err.Error(), "request_id") {
+		t.Errorf("error = %q with RequestID %q, want no request ID", err, apiErr.RequestID)
+	}
+}
+
+func TestFieldErrors(t *testing.T) {
+	tests := []struct {
+		name       string
+		body       string
+		field      string
+		wantMsg    string // "" if field has no error
+		wantInText string
+	}{
+		{
+			name:       "multiple fields",
+			body:       `{"code":"validation_error","message":"invalid","errors":[{"field":"email","message":"is invalid"},{"field":"name","message":"is required"}]}`,
+			field:      "name",
+			wantMsg:    "is required",
+			wantInText: "(email: is invalid; name: is required)",
+		},
+		{
+			name:       "single field",
+			body:       `{"code":"validation_error","message":"is required","field":"name"}`,
+			field:      "name",
+			wantMsg:    "is required",
+			wantInText: "(field: name)",
+		},
+		{
+			name:       "other field",
+			body:       `{"code":"validation_error","message":"is invalid","errors":[{"field":"email","message":"is invalid"}]}`,
+			field:      "name",
+			wantInText: "(email: is invalid)",
+		},
+		{
+			name:       "no fields",
+			body:       `{"code":"validation_error","message":"bad request"}`,
+			field:      "",
+			wantInText: "validation_error - bad request",
+		},
+		{
+			name:       "malformed JSON",
+			body:       `{"code":"validation_error","errors":[{"field":`,
+			field:      "name",
+			wantInText: `Bad Request: {"code":"validation_error"`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			apiErr := parseError(http.StatusBadRequest, "application/json", []byte(tt.body))
+			if !IsValidationError(apiErr) {
+				t.Errorf("IsValidationError = false")
+			}
+			fe := apiErr.FieldError(tt.field)
+			switch {
+			case tt.wantMsg == "" && fe != nil:
+				t.Errorf("FieldError(%q) = %+v, want nil", tt.field, fe)
+			case tt.wantMsg != "" && (fe == nil || fe.Field != tt.field || fe.Message != tt.wantMsg):
+				t.Errorf("FieldError(%q) = %+v, want %q", tt.field, fe, tt.wantMsg)
+			}
+			if !strings.Contains(apiErr.Error(), tt.wantInText) {
+				t.Errorf("Error() = %q, want it to contain %q", apiErr.Error(), tt.wantInText)
+			}
+		})
+	}
+}
+
+func TestErrorHelpersUnwrap(t *testing.T) {
+	tests := []struct {
+		name string
+		err  *Error
+		is   func(error) bool
+		want error
+	}{
+		{"not found", &Error{StatusCode: 404}, IsNotFound, ErrNotFound},
+		{"validation", &Error{StatusCode: 400}, IsValidationError, ErrValidation},
+		{"unauthorized", &Error{StatusCode: 401}, IsUnauthorized, ErrUnauthorized},
+		{"forbidden", &Error{StatusCode: 403}, IsForbidden, ErrForbidden},
+		{"conflict", &Error{StatusCode: 409}, IsConflict, ErrConflict},
+		{"rate limited", &Error{StatusCode: 429}, IsRateLimited, ErrRateLimited},
+		{"subscription paused", &Error{StatusCode: 409, Code: "subscription_paused"}, IsSubscriptionPaused, ErrSubscriptionPaused},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			wrapped := map[string]error{
+				"bare":       tt.err,
+				"one level":  fmt.Errorf("get plan: %w", tt.err),
+				"two levels": fmt.Errorf("sync: %w", fmt.Errorf("get plan: %w", tt.err)),
+				"joined":     errors.Join(errors.New("other"), fmt.Errorf("get plan: %w", tt.err)),
+			}
+			for depth, err := range wrapped {
+				if !tt.is(err) || !errors.Is(err, tt.want) {
+					t.Errorf("%s: helper = %v, errors.Is = %v, want both true", depth, tt.is(err), errors.Is(err, tt.want))
+				}
+				if got, ok := AsError(err); !ok || got != tt.err {
+					t.Errorf("%s: AsError = %v, %v; want the original *Error", depth, got, ok)
+				}
+			}
+			if tt.is(fmt.Errorf("wrapped: %w", &Error{StatusCode: 500})) {
+				t.Errorf("helper matched a 500")
+			}
+		})
+	}
+	if IsNotFound(nil) || IsServerError(nil) {
+		t.Errorf("helpers matched a nil error")
+	}
+	if !IsServerError(fmt.Errorf("a: %w", fmt.Errorf("b: %w", &Error{StatusCode: 502}))) {
+		t.Errorf("IsServerError didn't match a 502 wrapped twice")
+	}
+}
+
+func TestCallTimeout(t *testing.T) {
+	release := make(chan struct{})
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	})
+	defer close(release)
+
+	tests := []struct {
+		name string
+		c    *Client
+		opts []RequestOption
+	}{
+		{"WithTimeout", c, []RequestOption{WithTimeout(20 * time.Millisecond)}},
+		{"WithDefaultTimeout", c.WithDefaultTimeout(20 * time.Millisecond), nil},
+		{"WithTimeout overrides the default", c.WithDefaultTimeout(time.Hour), []RequestOption{WithTimeout(20 * time.Millisecond)}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start := time.Now()
+			_, err := tt.c.Billing.GetPlan(context.Background(), "plan_1", tt.opts...)
+			if !errors.Is(err, context.DeadlineExceeded) {
+				t.Errorf("GetPlan error = %v, want DeadlineExceeded", err)
+			}
+			if elapsed := time.Since(start); elapsed > 2*time.Second {
+				t.Errorf("GetPlan took %v, want it cut off by the timeout", elapsed)
+			}
+		})
+	}
+	if c.httpClient.Timeout != 30*time.Second {
+		t.Errorf("http.Client timeout = %v, want the per-call timeouts to leave it alone", c.httpClient.Timeout)
+	}
+}
+
+type panickingObserver struct{ calls atomic.Int32 }
+
+func (o *panickingObserver) ObserveRequest(op, method string, status int, duration time.Duration, err error) {
+	o.calls.Add(1)
+	panic("observer bug")
+}
+
+func TestPanickingMetricsObserver(t *testing.T) {
+	var logs strings.Builder
+	obs := &panickingObserver{}
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"id":"plan_1"}`))
+	})
+	c = c.derive(WithMetricsObserver(obs)).WithLogger(slog.New(slog.NewTextHandler(&logs, nil)))
+
+	plan, err := c.Billing.GetPlan(context.Background(), "plan_1")
+	if err != nil || plan.ID != "plan_1" {
+		t.Fatalf("GetPlan = %+v, %v; want the plan despite the observer panic", plan, err)
+	}
+	if obs.calls.Load() != 1 {
+		t.Errorf("observer called %d times, want 1", obs.calls.Load())
+	}
+	if !strings.Contains(logs.String(), "metrics observer panicked") {
+		t.Errorf("logs = %q, want the panic logged", logs.String())
+	}
+}
+
+func gzipped(t *testing.T, s string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write([]byte(s)); err != nil {
+		t.Fatal(err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestGzipResponses(t *testing.T) {
+	plan := `{"id":"plan_1","key":"pro","name":"` + strings.Repeat("x", 1000) + `"}`
+	full := gzipped(t, plan)
+
+	tests := []struct {
+		name     string
+		encoding string
+		body     []byte
+		wantErr  string
+	}{
+		{"gzip", "gzip", full, ""},
+		{"identity", "", []byte(plan), ""},
+		{"truncated gzip", "gzip", full[:len(full)/2], "decompress response"},
+		{"not gzip", "gzip", []byte(plan), "decompress response"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+				if got := r.Header.Get("Accept-Encoding"); got != "gzip" {
+					t.Errorf("Accept-Encoding = %q, want gzip", got)
+				}
+				if tt.encoding != "" {
+					w.Header().Set("Content-Encoding", tt.encoding)
+				}
+				w.Write(tt.body)
+			})
+			got, err := c.Billing.GetPlan(context.Background(), "plan_1")
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("GetPlan error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("GetPlan: %v", err)
+			}
+			if got.Key != "pro" || len(got.Name) != 1000 {
+				t.Errorf("decoded plan = %q/%d, want pro with the full name", got.Key, len(got.Name))
+			}
+		})
+	}
+}
+
+func TestGzipErrorBody(t *testing.T) {
+	body := gzipped(t, `{"code":"not_found","message":"plan not found"}`)
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Encoding", "gzip")
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusNotFound)
+		w.Write(body)
+	})
+	_, err := c.Billing.GetPlan(context.Background(), "plan_1")
+	if apiErr, ok := AsError(err); !ok || apiErr.Code != "not_found" || apiErr.Message != "plan not found" {
+		t.Errorf("GetPlan error = %v, want the decoded not_found error", err)
+	}
+}
+
+func TestNonJSONErrorBodies(t *testing.T) {
+	tests := []struct {
+		name        string
+		status      int
+		contentType string
+		body        string
+		wantCode    string
+		wantMessage string
+	}{
+		{
+			name:        "HTML",
+			status:      http.StatusBadGateway,
+			contentType: "text/html",
+			body:        "<html>\n  <body>502 Bad Gateway</body>\n</html>",
+			wantCode:    "http_error",
+			wantMessage: "Bad Gateway: <html> <body>502 Bad Gateway</body> </html>",
+		},
+		{
+			name:        "plain text",
+			status:      http.StatusServiceUnavailable,
+			contentType: "text/plain",
+			body:        "upstream connect error",
+			wantCode:    "http_error",
+			wantMessage: "Service Unavailable: upstream connect error",
+		},
+		{
+			name:        "empty",
+			status:      http.StatusInternalServerError,
+			wantCode:    "http_error",
+			wantMessage: "Internal Server Error (empty response body)",
+		},
+		{
+			name:        "JSON",
+			status:      http.StatusNotFound,
+			contentType: "application/json; charset=utf-8",
+			body:        `{"code":"not_found","message":"plan not found"}`,
+			wantCode:    "not_found",
+			wantMessage: "plan not found",
+		},
+		{
+			name:        "JSON without a content type",
+			status:      http.StatusConflict,
+			body:        `{"code":"conflict","message":"key taken"}`,
+			wantCode:    "conflict",
+			wantMessage: "key taken",
+		},
+		{
+			name:        "JSON content type with an HTML body",
+			status:      http.StatusBadGateway,
+			contentType: "application/json",
+			body:        "<h1>Bad Gateway</h1>",
+			wantCode:    "http_error",
+			wantMessage: "Bad Gateway: <h1>Bad Gateway</h1>",
+		},
+		{
+			name:        "unknown status",
+			status:      599,
+			contentType: "text/plain",
+			body:        "oops",
+			wantCode:    "http_error",
+			wantMessage: "HTTP 599: oops",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			apiErr := parseError(tt.status, tt.contentType, []byte(tt.body))
+			if apiErr.StatusCode != tt.status || apiErr.Code != tt.wantCode || apiErr.Message != tt.wantMessage {
+				t.Errorf("parseError = %d %q %q, want %d %q %q",
+					apiErr.StatusCode, apiErr.Code, apiErr.Message, tt.status, tt.wantCode, tt.wantMessage)
+			}
+		})
+	}
+
+	long := parseError(http.StatusBadGateway, "text/html", []byte(strings.Repeat("é", maxErrorBodyLength)))
+	if !strings.HasSuffix(long.Message, "...") || !utf8.ValidString(long.Message) || len(long.Message) > maxErrorBodyLength+len("Bad Gateway: ...") {
+		t.Errorf("long body message = %d bytes, want it truncated to valid UTF-8", len(long.Message))
+	}
+}
@@ -0,0 +1,93 @@
+// Package tedometrics provides a MetricsObserver that keeps request counts
+// and latencies in memory, for applications without a metrics system.
+//
+//	counters := tedometrics.New()
+//	expvar.Publish("tedo", counters)
+//	client := tedo.NewClient(apiKey, tedo.WithMetricsObserver(counters))
+package tedometrics
+
+import (
+	"encoding/json"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Stats summarizes the request attempts with one operation and status
+// class.
+type Stats struct {
+	Count         int64         `json:"count"`
+	TotalDuration time.Duration `json:"total_duration_ns"`
+	MaxDuration   time.Duration `json:"max_duration_ns"`
+}
+
+// Key identifies a group of request attempts.
+type Key struct {
+	Operation   string // e.g. "billing.CreateSubscription", empty for Do
+	StatusClass string // "2xx", "4xx", "5xx", ... or "error" without a response
+}
+
+// Counters implements tedo.MetricsObserver by counting attempts per Key. It
+// implements expvar.Var, so it can be published directly. It is safe for
+// concurrent use.
+type Counters struct {
+	mu    sync.Mutex
+	stats map[Key]*Stats
+}
+
+// New returns empty counters.
+func New() *Counters {
+	return &Counters{stats: make(map[Key]*Stats)}
+}
+
+// ObserveRequest records one request attempt.
+func (c *Counters) ObserveRequest(op, method string, status int, duration time.Duration, err error) {
+	key := Key{Operation: op, StatusClass: statusClass(status)}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	s := c.stats[key]
+	if s == nil {
+		s = &Stats{}
+		c.stats[key] = s
+	}
+	s.Count++
+	s.TotalDuration += duration
+	if duration > s.MaxDuration {
+		s.MaxDuration = duration
+	}
+}
+
+// Snapshot returns a copy of the current counts.
+func (c *Counters) Snapshot() map[Key]Stats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	snapshot := make(map[Key]Stats, len(c.stats))
+	for key, s := range c.stats {
+		snapshot[key] = *s
+	}
+	return snapshot
+}
+
+// String returns the counts as JSON, keyed by operation and then status
+// class, for expvar.
+func (c *Counters) String() string {
+	byOp := make(map[string]map[string]Stats)
+	for key, s := range c.Snapshot() {
+		if byOp[key.Operation] == nil {
+			byOp[key.Operation] = make(map[string]Stats)
+		}
+		byOp[key.Operation][key.StatusClass] = s
+	}
+	b, _ := json.Marshal(byOp)
+	return string(b)
+}
+
+func statusClass(status int) string {
+	if status < 100 || status > 599 {
+		return "error"
+	}
+	return strconv.Itoa(status/100) + "xx"
+}
@@ -0,0 +1,71 @@
+package tedometrics_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/tedo-ai/tedo-go"
+	"github.com/tedo-ai/tedo-go/tedometrics"
+)
+
+func TestCountersConcurrent(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/billing/v1/plans/missing" {
+			http.Error(w, `{"code":"not_found","message":"plan not found"}`, http.StatusNotFound)
+			return
+		}
+		w.Write([]byte(`{"id":"plan_1"}`))
+	}))
+	t.Cleanup(srv.Close)
+
+	counters := tedometrics.New()
+	client := tedo.NewClient("tedo_test_key", tedo.WithBaseURL(srv.URL), tedo.WithMetricsObserver(counters))
+
+	const workers, calls = 8, 25
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < calls; j++ {
+				client.Billing.GetPlan(context.Background(), "plan_1")
+				client.Billing.GetPlan(context.Background(), "missing")
+				_ = counters.String()
+			}
+		}()
+	}
+	wg.Wait()
+
+	snapshot := counters.Snapshot()
+	ok := snapshot[tedometrics.Key{Operation: "billing.GetPlan", StatusClass: "2xx"}]
+	missing := snapshot[tedometrics.Key{Operation: "billing.GetPlan", StatusClass: "4xx"}]
+	if ok.Count != workers*calls || missing.Count != workers*calls {
+		t.Errorf("counts = %d 2xx and %d 4xx, want %d each", ok.Count, missing.Count, workers*calls)
+	}
+	if ok.MaxDuration <= 0 || ok.TotalDuration < ok.MaxDuration {
+		t.Errorf("durations = %+v, want a positive maximum within the total", ok)
+	}
+
+	var published map[string]map[string]tedometrics.Stats
+	if err := json.Unmarshal([]byte(counters.String()), &published); err != nil {
+		t.Fatalf("String() is not JSON: %v", err)
+	}
+	if published["billing.GetPlan"]["4xx"].Count != workers*calls {
+		t.Errorf("published = %v", published)
+	}
+}
+
+func TestCountersTransportError(t *testing.T) {
+	counters := tedometrics.New()
+	client := tedo.NewClient("tedo_test_key", tedo.WithBaseURL("http://127.0.0.1:1"), tedo.WithMetricsObserver(counters))
+	if _, err := client.Billing.GetPlan(context.Background(), "plan_1"); err == nil {
+		t.Fatal("GetPlan succeeded without a server")
+	}
+	if n := counters.Snapshot()[tedometrics.Key{Operation: "billing.GetPlan", StatusClass: "error"}].Count; n != 1 {
+		t.Errorf("error count = %d, want 1", n)
+	}
+}
@@ -0,0 +1,79 @@
+// Package tedootel traces Tedo API calls with OpenTelemetry.
+//
+//	client := tedo.NewClient(apiKey, tedootel.WithTracerProvider(tp))
+//
+// Each call made through a service method gets a client span named after
+// the operation, e.g. "billing.CreateSubscription", with retries recorded
+// on the same span. The span context is propagated to the API with the
+// global text map propagator.
+package tedootel
+
+import (
+	"context"
+	"net/http"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/tedo-ai/tedo-go"
+)
+
+const instrumentationName = "github.com/tedo-ai/tedo-go/tedootel"
+
+// WithTracerProvider traces every API call with a tracer from tp. A nil tp
+// uses the global tracer provider.
+//
+// Spans carry the HTTP method, the path template (with IDs left as
+// placeholders), the final status code and the number of retries.
+func WithTracerProvider(tp trace.TracerProvider) tedo.ClientOption {
+	if tp == nil {
+		tp = otel.GetTracerProvider()
+	}
+	tracer := tp.Tracer(instrumentationName, trace.WithInstrumentationVersion(tedo.Version))
+
+	return func(c *tedo.Client) {
+		tedo.WithCallHook(callHook(tracer))(c)
+		tedo.WithMiddleware(propagate)(c)
+	}
+}
+
+func callHook(tracer trace.Tracer) tedo.CallHook {
+	return func(ctx context.Context, call tedo.CallInfo) (context.Context, func(tedo.CallResult)) {
+		name := call.Operation
+		if name == "" {
+			name = "tedo " + call.Method
+		}
+		ctx, span := tracer.Start(ctx, name,
+			trace.WithSpanKind(trace.SpanKindClient),
+			trace.WithAttributes(
+				attribute.String("http.request.method", call.Method),
+				attribute.String("url.template", call.PathTemplate),
+			),
+		)
+
+		return ctx, func(result tedo.CallResult) {
+			if result.StatusCode != 0 {
+				span.SetAttributes(attribute.Int("http.response.status_code", result.StatusCode))
+			}
+			if result.Attempts > 1 {
+				span.SetAttributes(attribute.Int("http.request.resend_count", result.Attempts-1))
+			}
+			if result.Err != nil {
+				span.RecordError(result.Err)
+				span.SetStatus(codes.Error, result.Err.Error())
+			}
+			span.End()
+		}
+	}
+}
+
+// propagate injects the span context into the headers of each attempt.
+func propagate(next tedo.RoundTripFunc) tedo.RoundTripFunc {
+	return func(req *http.Request) (*http.Response, error) {
+		otel.GetTextMapPropagator().Inject(req.Context(), propagation.HeaderCarrier(req.Header))
+		return next(req)
+	}
+}
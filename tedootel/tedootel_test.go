@@ -0,0 +1,76 @@
+package tedootel_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/tedo-ai/tedo-go"
+	"github.com/tedo-ai/tedo-go/tedootel"
+)
+
+func TestWithTracerProvider(t *testing.T) {
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	var traceparent string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		traceparent = r.Header.Get("Traceparent")
+		if r.URL.Path == "/billing/v1/subscriptions/sub_missing" {
+			http.Error(w, `{"code":"not_found","message":"no such subscription"}`, http.StatusNotFound)
+			return
+		}
+		w.Write([]byte(`{"id":"sub_1"}`))
+	}))
+	defer srv.Close()
+
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	client := tedo.NewClient("tedo_test_key", tedo.WithBaseURL(srv.URL), tedootel.WithTracerProvider(tp))
+
+	ctx := context.Background()
+	if _, err := client.Billing.GetSubscription(ctx, "sub_1"); err != nil {
+		t.Fatalf("GetSubscription: %v", err)
+	}
+	if _, err := client.Billing.GetSubscription(ctx, "sub_missing"); !tedo.IsNotFound(err) {
+		t.Fatalf("GetSubscription error = %v, want not found", err)
+	}
+
+	spans := exporter.GetSpans()
+	if len(spans) != 2 {
+		t.Fatalf("got %d spans, want 2", len(spans))
+	}
+	ok, failed := spans[0], spans[1]
+	if ok.Name != "billing.GetSubscription" || ok.SpanKind != trace.SpanKindClient {
+		t.Errorf("span = %q (%v), want client span billing.GetSubscription", ok.Name, ok.SpanKind)
+	}
+	attrs := map[attribute.Key]attribute.Value{}
+	for _, kv := range ok.Attributes {
+		attrs[kv.Key] = kv.Value
+	}
+	if got := attrs["url.template"].AsString(); got != "/billing/v1/subscriptions/{subscriptionID}" {
+		t.Errorf("url.template = %q", got)
+	}
+	if got := attrs["http.request.method"].AsString(); got != "GET" {
+		t.Errorf("http.request.method = %q", got)
+	}
+	if got := attrs["http.response.status_code"].AsInt64(); got != 200 {
+		t.Errorf("http.response.status_code = %d", got)
+	}
+	if ok.Status.Code == codes.Error {
+		t.Errorf("successful call has error status")
+	}
+	if failed.Status.Code != codes.Error || len(failed.Events) == 0 {
+		t.Errorf("failed call: status %v with %d events, want recorded error", failed.Status.Code, len(failed.Events))
+	}
+	if want := failed.SpanContext.TraceID().String(); traceparent == "" || traceparent[3:35] != want {
+		t.Errorf("traceparent = %q, want trace ID %s", traceparent, want)
+	}
+}
@@ -0,0 +1,1271 @@
+// Package tedotest provides an in-memory fake of the Tedo billing API for
+// tests of code that uses the tedo package.
+//
+// The fake implements the plan, price, entitlement, customer, subscription,
+// entitlement check and usage endpoints, and answers with the same JSON
+// shapes as the real API. IDs are assigned sequentially ("plan_1",
+// "cus_2", ...), so tests are deterministic.
+//
+//	srv := tedotest.NewServer(t)
+//	plan := srv.SeedPlan(tedo.Plan{Key: "pro", Name: "Pro"})
+//	client := srv.Client()
+//
+// Use NewClient when the test sets up its data through the client alone.
+package tedotest
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/tedo-ai/tedo-go"
+)
+
+// ReactivationWindow is how long after cancellation the fake lets a
+// subscription be reactivated.
+const ReactivationWindow = 30 * 24 * time.Hour
+
+// APIKey is the API key of clients returned by Server.Client. The fake
+// accepts any bearer token but rejects requests without one.
+const APIKey = "tedo_test_fake"
+
+// Server is a fake Tedo API backed by in-memory state. It is safe for
+// concurrent use.
+type Server struct {
+	// URL is the base URL of the fake, for use with tedo.WithBaseURL.
+	URL string
+
+	mu            sync.Mutex
+	nextID        int
+	plans         []*tedo.Plan
+	prices        []*tedo.Price
+	entitlements  []*tedo.Entitlement
+	customers     []*tedo.Customer
+	subscriptions []*tedo.Subscription
+	usage         []*tedo.UsageRecord
+}
+
+// NewServer starts a fake server that is closed when the test ends.
+func NewServer(t testing.TB) *Server {
+	t.Helper()
+	s := &Server{}
+	hs := httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	t.Cleanup(hs.Close)
+	s.URL = hs.URL
+	return s
+}
+
+// NewClient starts a fake server and returns a client wired to it. Use
+// NewServer instead when the test needs to seed data directly.
+func NewClient(t testing.TB) *tedo.Client {
+	t.Helper()
+	return NewServer(t).Client()
+}
+
+// Client returns a new client that sends its requests to the fake.
+func (s *Server) Client() *tedo.Client {
+	return tedo.NewClient(APIKey, tedo.WithBaseURL(s.URL))
+}
+
+// SeedPlan stores a plan and returns it with its ID and timestamps filled
+// in. Prices and entitlements nested in the plan are stored as well. The
+// plan is stored as active, like one created with CreatePlan, whatever its
+// IsActive; archive it with DeletePlan to test inactive plans.
+func (s *Server) SeedPlan(plan tedo.Plan) tedo.Plan {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	p := plan
+	p.IsActive = true
+	s.fill(&p.ID, "plan", &p.CreatedAt.Time)
+	p.Prices, p.Entitlements = nil, nil
+	s.plans = append(s.plans, &p)
+	for _, price := range plan.Prices {
+		price.PlanID = p.ID
+		s.seedPrice(price)
+	}
+	for _, entitlement := range plan.Entitlements {
+		entitlement.PlanID = p.ID
+		s.seedEntitlement(entitlement)
+	}
+	return s.planView(&p)
+}
+
+// SeedPrice stores a price for the plan with ID price.PlanID and returns it
+// with its ID filled in.
+func (s *Server) SeedPrice(price tedo.Price) tedo.Price {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return *s.seedPrice(price)
+}
+
+// SeedEntitlement stores an entitlement for the plan with ID
+// entitlement.PlanID and returns it with its ID filled in.
+func (s *Server) SeedEntitlement(entitlement tedo.Entitlement) tedo.Entitlement {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return *s.seedEntitlement(entitlement)
+}
+
+// SeedCustomer stores a customer and returns it with its ID filled in.
+func (s *Server) SeedCustomer(customer tedo.Customer) tedo.Customer {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	c := customer
+	s.fill(&c.ID, "cus", &c.CreatedAt.Time)
+	c.Subscriptions = nil
+	s.customers = append(s.customers, &c)
+	return s.customerView(&c)
+}
+
+// SeedSubscription stores a subscription and returns it with its ID filled
+// in. Status defaults to active, and PlanKey and PriceKey are derived from
+// PriceID when it names a stored price.
+func (s *Server) SeedSubscription(subscription tedo.Subscription) tedo.Subscription {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return *s.seedSubscription(subscription)
+}
+
+// UsageRecords returns a copy of all usage recorded so far, oldest first.
+func (s *Server) UsageRecords() []tedo.UsageRecord {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	records := make([]tedo.UsageRecord, len(s.usage))
+	for i, r := range s.usage {
+		records[i] = *r
+	}
+	return records
+}
+
+func (s *Server) seedPrice(price tedo.Price) *tedo.Price {
+	p := price
+	s.fill(&p.ID, "price", &p.CreatedAt.Time)
+	if p.Currency == "" {
+		p.Currency = "eur"
+	}
+	if p.Interval == "" {
+		p.Interval = "month"
+	}
+	if p.IntervalCount == 0 {
+		p.IntervalCount = 1
+	}
+	s.prices = append(s.prices, &p)
+	return &p
+}
+
+func (s *Server) seedEntitlement(entitlement tedo.Entitlement) *tedo.Entitlement {
+	e := entitlement
+	s.fill(&e.ID, "ent", &e.CreatedAt)
+	e.PlanKey = ""
+	s.entitlements = append(s.entitlements, &e)
+	return &e
+}
+
+func (s *Server) seedSubscription(subscription tedo.Subscription) *tedo.Subscription {
+	sub := subscription
+	s.fill(&sub.ID, "sub", &sub.CreatedAt.Time)
+	if sub.Status == "" {
+		sub.Status = string(tedo.SubscriptionStatusActive)
+	}
+	if sub.StartedAt.IsZero() {
+		sub.StartedAt = sub.CreatedAt
+	}
+	if sub.CurrentPeriodEnd.IsZero() {
+		sub.CurrentPeriodEnd = tedo.Time{Time: sub.StartedAt.AddDate(0, 1, 0)}
+	}
+	if price := s.findPrice(sub.PriceID); price != nil {
+		sub.PriceKey = price.Key
+		if plan := s.findPlan(price.PlanID); plan != nil {
+			sub.PlanKey = plan.Key
+		}
+	}
+	s.subscriptions = append(s.subscriptions, &sub)
+	return &sub
+}
+
+// fill assigns the next sequential ID and the current time unless they are
+// already set.
+func (s *Server) fill(id *string, prefix string, createdAt *time.Time) {
+	s.nextID++
+	if *id == "" {
+		*id = prefix + "_" + strconv.Itoa(s.nextID)
+	}
+	if createdAt.IsZero() {
+		*createdAt = now().Time
+	}
+}
+
+// now returns the current time at the API's one-second resolution.
+func now() tedo.Time {
+	return tedo.Time{Time: time.Now().UTC().Truncate(time.Second)}
+}
+
+// ==== ROUTING ====
+
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	if !strings.HasPrefix(r.Header.Get("Authorization"), "Bearer ") {
+		writeError(w, http.StatusUnauthorized, "unauthorized", "missing API key", "")
+		return
+	}
+
+	var segments []string
+	for _, seg := range strings.Split(strings.TrimPrefix(r.URL.EscapedPath(), "/billing/v1/"), "/") {
+		unescaped, err := url.PathUnescape(seg)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "validation_error", "malformed path", "")
+			return
+		}
+		segments = append(segments, unescaped)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	route := r.Method + " " + pattern(segments)
+	switch route {
+	case "POST plans":
+		s.createPlan(w, r)
+	case "GET plans":
+		s.listPlans(w, r)
+	case "GET plans/*":
+		s.getPlan(w, segments[1])
+	case "PATCH plans/*":
+		s.updatePlan(w, r, segments[1])
+	case "DELETE plans/*":
+		s.deletePlan(w, segments[1])
+	case "POST plans/*/prices":
+		s.createPrice(w, r, segments[1])
+	case "GET plans/*/prices":
+		s.listPrices(w, segments[1])
+	case "GET plans/*/prices/*":
+		s.getPrice(w, segments[1], segments[3])
+	case "DELETE plans/*/prices/*":
+		s.archivePrice(w, segments[1], segments[3])
+	case "POST plans/*/entitlements":
+		s.createEntitlement(w, r, segments[1])
+	case "GET plans/*/entitlements":
+		s.listEntitlements(w, segments[1])
+	case "DELETE plans/*/entitlements/*":
+		s.archiveEntitlement(w, segments[1], segments[3])
+	case "GET prices":
+		s.listAllPrices(w, r)
+	case "GET prices/*":
+		s.getPrice(w, "", segments[1])
+	case "POST customers":
+		s.createCustomer(w, r)
+	case "GET customers":
+		s.listCustomers(w, r)
+	case "GET customers/*":
+		s.getCustomer(w, segments[1])
+	case "PATCH customers/*":
+		s.updateCustomer(w, r, segments[1])
+	case "DELETE customers/*":
+		s.deleteCustomer(w, segments[1])
+	case "POST subscriptions":
+		s.createSubscription(w, r)
+	case "GET subscriptions":
+		s.listSubscriptions(w, r)
+	case "GET subscriptions/*":
+		s.getSubscription(w, segments[1])
+	case "PATCH subscriptions/*":
+		s.updateSubscription(w, r, segments[1])
+	case "DELETE subscriptions/*":
+		s.cancelSubscription(w, r, segments[1])
+	case "POST subscriptions/*/preview":
+		s.previewSubscriptionChange(w, r, segments[1])
+	case "POST subscriptions/*/reactivate":
+		s.reactivateSubscription(w, segments[1])
+	case "POST subscriptions/*/pause":
+		s.pauseSubscription(w, r, segments[1])
+	case "POST subscriptions/*/resume":
+		s.resumeSubscription(w, segments[1])
+	case "GET subscriptions/*/entitlements/*":
+		s.checkSubscriptionEntitlement(w, segments[1], segments[3])
+	case "POST entitlements/check":
+		s.checkEntitlement(w, r)
+	case "POST usage":
+		s.recordUsage(w, r)
+	case "GET usage":
+		s.usageSummary(w, r)
+	default:
+		writeError(w, http.StatusNotFound, "not_found", "no route for "+r.Method+" "+r.URL.Path, "")
+	}
+}
+
+// pattern replaces the ID segments of a billing path with "*", e.g.
+// "plans/plan_1/prices" becomes "plans/*/prices". Odd segments are IDs,
+// except for the fixed "entitlements/check".
+func pattern(segments []string) string {
+	if len(segments) == 2 && segments[0] == "entitlements" && segments[1] == "check" {
+		return "entitlements/check"
+	}
+	p := make([]string, len(segments))
+	for i, seg := range segments {
+		if i%2 == 1 {
+			seg = "*"
+		}
+		p[i] = seg
+	}
+	return strings.Join(p, "/")
+}
+
+// ==== PLANS ====
+
+func (s *Server) createPlan(w http.ResponseWriter, r *http.Request) {
+	var params tedo.CreatePlanParams
+	if !decode(w, r, &params) {
+		return
+	}
+	if params.Key == "" {
+		writeError(w, http.StatusBadRequest, "validation_error", "key is required", "key")
+		return
+	}
+	for _, p := range s.plans {
+		if p.Key == params.Key {
+			writeError(w, http.StatusConflict, "conflict", "a plan with this key already exists", "key")
+			return
+		}
+	}
+	plan := &tedo.Plan{Key: params.Key, Name: params.Name, Description: params.Description, IsActive: true}
+	s.fill(&plan.ID, "plan", &plan.CreatedAt.Time)
+	s.plans = append(s.plans, plan)
+	writeJSON(w, http.StatusCreated, s.planView(plan))
+}
+
+func (s *Server) listPlans(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	var plans []*tedo.Plan
+	for _, p := range s.plans {
+		if active := query.Get("is_active"); active != "" && strconv.FormatBool(p.IsActive) != active {
+			continue
+		}
+		if key := query.Get("key"); key != "" && p.Key != key {
+			continue
+		}
+		plans = append(plans, p)
+	}
+
+	start, end, next := paginate(r, len(plans))
+	list := tedo.PlanList{Plans: []tedo.Plan{}, Total: len(plans), NextCursor: next}
+	for _, p := range plans[start:end] {
+		list.Plans = append(list.Plans, s.planView(p))
+	}
+	writeJSON(w, http.StatusOK, list)
+}
+
+func (s *Server) getPlan(w http.ResponseWriter, id string) {
+	plan := s.findPlan(id)
+	if plan == nil {
+		writeNotFound(w, "plan")
+		return
+	}
+	writeJSON(w, http.StatusOK, s.planView(plan))
+}
+
+func (s *Server) updatePlan(w http.ResponseWriter, r *http.Request, id string) {
+	plan := s.findPlan(id)
+	if plan == nil {
+		writeNotFound(w, "plan")
+		return
+	}
+	var params tedo.UpdatePlanParams
+	if !decode(w, r, &params) {
+		return
+	}
+	if params.Key != nil {
+		plan.Key = *params.Key
+	}
+	if params.Name != nil {
+		plan.Name = *params.Name
+	}
+	if params.Description != nil {
+		plan.Description = *params.Description
+	}
+	if params.IsActive != nil {
+		plan.IsActive = *params.IsActive
+	}
+	plan.UpdatedAt = now()
+	writeJSON(w, http.StatusOK, s.planView(plan))
+}
+
+func (s *Server) deletePlan(w http.ResponseWriter, id string) {
+	plan := s.findPlan(id)
+	if plan == nil {
+		writeNotFound(w, "plan")
+		return
+	}
+	plan.IsActive = false
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) findPlan(id string) *tedo.Plan {
+	for _, p := range s.plans {
+		if p.ID == id {
+			return p
+		}
+	}
+	return nil
+}
+
+// planView returns a copy of plan with its prices and entitlements.
+func (s *Server) planView(plan *tedo.Plan) tedo.Plan {
+	p := *plan
+	p.Prices, p.Entitlements = nil, nil
+	for _, price := range s.prices {
+		if price.PlanID == p.ID {
+			p.Prices = append(p.Prices, *price)
+		}
+	}
+	for _, e := range s.entitlements {
+		if e.PlanID == p.ID {
+			p.Entitlements = append(p.Entitlements, *e)
+		}
+	}
+	return p
+}
+
+// ==== PRICES ====
+
+func (s *Server) createPrice(w http.ResponseWriter, r *http.Request, planID string) {
+	if s.findPlan(planID) == nil {
+		writeNotFound(w, "plan")
+		return
+	}
+	var params tedo.CreatePriceParams
+	if !decode(w, r, &params) {
+		return
+	}
+	if params.Key == "" {
+		writeError(w, http.StatusBadRequest, "validation_error", "key is required", "key")
+		return
+	}
+	price := s.seedPrice(tedo.Price{
+		PlanID:        planID,
+		Key:           params.Key,
+		Amount:        params.Amount,
+		Currency:      params.Currency,
+		Interval:      params.Interval,
+		IntervalCount: params.IntervalCount,
+		TrialDays:     params.TrialDays,
+		BillingScheme: params.BillingScheme,
+		TiersMode:     params.TiersMode,
+		Tiers:         params.Tiers,
+	})
+	writeJSON(w, http.StatusCreated, price)
+}
+
+func (s *Server) listPrices(w http.ResponseWriter, planID string) {
+	if s.findPlan(planID) == nil {
+		writeNotFound(w, "plan")
+		return
+	}
+	list := tedo.PriceList{Prices: []tedo.Price{}}
+	for _, p := range s.prices {
+		if p.PlanID == planID {
+			list.Prices = append(list.Prices, *p)
+		}
+	}
+	list.Total = len(list.Prices)
+	writeJSON(w, http.StatusOK, list)
+}
+
+// listAllPrices lists prices grouped by plan, in the order plans and prices
+// were created.
+func (s *Server) listAllPrices(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	var prices []tedo.Price
+	for _, plan := range s.plans {
+		if active := query.Get("is_active"); active != "" && strconv.FormatBool(plan.IsActive) != active {
+			continue
+		}
+		for _, p := range s.prices {
+			if p.PlanID != plan.ID {
+				continue
+			}
+			if currency := query.Get("currency"); currency != "" && !strings.EqualFold(p.Currency, currency) {
+				continue
+			}
+			if interval := query.Get("interval"); interval != "" && p.Interval != interval {
+				continue
+			}
+			price := *p
+			price.PlanKey = plan.Key
+			prices = append(prices, price)
+		}
+	}
+
+	start, end, next := paginate(r, len(prices))
+	list := tedo.PriceList{Prices: []tedo.Price{}, Total: len(prices), NextCursor: next}
+	list.Prices = append(list.Prices, prices[start:end]...)
+	writeJSON(w, http.StatusOK, list)
+}
+
+// getPrice serves a price, checking that it belongs to planID unless planID
+// is empty.
+func (s *Server) getPrice(w http.ResponseWriter, planID, priceID string) {
+	price := s.findPrice(priceID)
+	if price == nil || (planID != "" && price.PlanID != planID) {
+		writeNotFound(w, "price")
+		return
+	}
+	writeJSON(w, http.StatusOK, price)
+}
+
+func (s *Server) archivePrice(w http.ResponseWriter, planID, priceID string) {
+	for i, p := range s.prices {
+		if p.ID == priceID && p.PlanID == planID {
+			s.prices = append(s.prices[:i], s.prices[i+1:]...)
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+	}
+	writeNotFound(w, "price")
+}
+
+func (s *Server) findPrice(id string) *tedo.Price {
+	for _, p := range s.prices {
+		if p.ID == id {
+			return p
+		}
+	}
+	return nil
+}
+
+// ==== ENTITLEMENTS ====
+
+func (s *Server) createEntitlement(w http.ResponseWriter, r *http.Request, planID string) {
+	if s.findPlan(planID) == nil {
+		writeNotFound(w, "plan")
+		return
+	}
+	var params tedo.CreateEntitlementParams
+	if !decode(w, r, &params) {
+		return
+	}
+	if params.Key == "" {
+		writeError(w, http.StatusBadRequest, "validation_error", "key is required", "key")
+		return
+	}
+	entitlement := s.seedEntitlement(tedo.Entitlement{
+		PlanID:       planID,
+		Key:          params.Key,
+		ValueBool:    params.ValueBool,
+		ValueInt:     params.ValueInt,
+		OveragePrice: params.OveragePrice,
+		OverageUnit:  params.OverageUnit,
+	})
+	writeJSON(w, http.StatusCreated, entitlement)
+}
+
+func (s *Server) listEntitlements(w http.ResponseWriter, planID string) {
+	if s.findPlan(planID) == nil {
+		writeNotFound(w, "plan")
+		return
+	}
+	list := tedo.EntitlementList{Entitlements: []tedo.Entitlement{}}
+	for _, e := range s.entitlements {
+		if e.PlanID == planID {
+			list.Entitlements = append(list.Entitlements, *e)
+		}
+	}
+	list.Total = len(list.Entitlements)
+	writeJSON(w, http.StatusOK, list)
+}
+
+func (s *Server) archiveEntitlement(w http.ResponseWriter, planID, entitlementID string) {
+	for i, e := range s.entitlements {
+		if e.ID == entitlementID && e.PlanID == planID {
+			s.entitlements = append(s.entitlements[:i], s.entitlements[i+1:]...)
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+	}
+	writeNotFound(w, "entitlement")
+}
+
+// ==== CUSTOMERS ====
+
+func (s *Server) createCustomer(w http.ResponseWriter, r *http.Request) {
+	var params tedo.CreateCustomerParams
+	if !decode(w, r, &params) {
+		return
+	}
+	if params.Email == "" {
+		writeError(w, http.StatusBadRequest, "validation_error", "email is required", "email")
+		return
+	}
+	if params.ExternalID != "" {
+		for _, c := range s.customers {
+			if c.ExternalID == params.ExternalID {
+				writeError(w, http.StatusConflict, "conflict", "a customer with this external_id already exists", "external_id")
+				return
+			}
+		}
+	}
+	customer := &tedo.Customer{
+		Email:      params.Email,
+		Name:       params.Name,
+		ExternalID: params.ExternalID,
+		Metadata:   params.Metadata,
+	}
+	s.fill(&customer.ID, "cus", &customer.CreatedAt.Time)
+	s.customers = append(s.customers, customer)
+	writeJSON(w, http.StatusCreated, s.customerView(customer))
+}
+
+func (s *Server) listCustomers(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	var customers []*tedo.Customer
+	for _, c := range s.customers {
+		if externalID := query.Get("external_id"); externalID != "" && c.ExternalID != externalID {
+			continue
+		}
+		if email := query.Get("email"); email != "" && c.Email != email {
+			continue
+		}
+		updatedAt := c.UpdatedAt
+		if updatedAt.IsZero() {
+			updatedAt = c.CreatedAt
+		}
+		if since, ok := parseTime(query.Get("updated_since")); ok && updatedAt.Before(since) {
+			continue
+		}
+		customers = append(customers, c)
+	}
+
+	start, end, next := paginate(r, len(customers))
+	list := tedo.CustomerList{Customers: []tedo.Customer{}, Total: len(customers), NextCursor: next}
+	for _, c := range customers[start:end] {
+		list.Customers = append(list.Customers, s.customerView(c))
+	}
+	writeJSON(w, http.StatusOK, list)
+}
+
+func (s *Server) getCustomer(w http.ResponseWriter, id string) {
+	customer := s.findCustomer(id)
+	if customer == nil {
+		writeNotFound(w, "customer")
+		return
+	}
+	writeJSON(w, http.StatusOK, s.customerView(customer))
+}
+
+func (s *Server) updateCustomer(w http.ResponseWriter, r *http.Request, id string) {
+	customer := s.findCustomer(id)
+	if customer == nil {
+		writeNotFound(w, "customer")
+		return
+	}
+	var params tedo.UpdateCustomerParams
+	if !decode(w, r, &params) {
+		return
+	}
+	if params.Email != nil {
+		customer.Email = *params.Email
+	}
+	if params.Name != nil {
+		customer.Name = *params.Name
+	}
+	if params.ExternalID != nil {
+		customer.ExternalID = *params.ExternalID
+	}
+	if params.Metadata != nil {
+		// An explicit empty object clears the metadata.
+		customer.Metadata = nil
+		if len(params.Metadata) > 0 {
+			customer.Metadata = params.Metadata
+		}
+	}
+	customer.UpdatedAt = now()
+	writeJSON(w, http.StatusOK, s.customerView(customer))
+}
+
+func (s *Server) deleteCustomer(w http.ResponseWriter, id string) {
+	for i, c := range s.customers {
+		if c.ID == id {
+			s.customers = append(s.customers[:i], s.customers[i+1:]...)
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+	}
+	writeNotFound(w, "customer")
+}
+
+func (s *Server) findCustomer(id string) *tedo.Customer {
+	for _, c := range s.customers {
+		if c.ID == id {
+			return c
+		}
+	}
+	return nil
+}
+
+// customerView returns a copy of customer with its subscriptions.
+func (s *Server) customerView(customer *tedo.Customer) tedo.Customer {
+	c := *customer
+	c.Subscriptions = nil
+	for _, sub := range s.subscriptions {
+		if sub.CustomerID == c.ID {
+			c.Subscriptions = append(c.Subscriptions, *sub)
+		}
+	}
+	return c
+}
+
+// ==== SUBSCRIPTIONS ====
+
+func (s *Server) createSubscription(w http.ResponseWriter, r *http.Request) {
+	var params tedo.CreateSubscriptionParams
+	if !decode(w, r, &params) {
+		return
+	}
+	if s.findCustomer(params.CustomerID) == nil {
+		writeNotFound(w, "customer")
+		return
+	}
+
+	price := s.resolvePrice(params.PriceID, params.PlanKey, params.PriceKey)
+	if price == nil {
+		writeError(w, http.StatusBadRequest, "validation_error", "unknown price", "price_id")
+		return
+	}
+
+	sub := tedo.Subscription{
+		CustomerID: params.CustomerID,
+		PriceID:    price.ID,
+		Status:     params.InitialStatus,
+		Quantity:   params.Quantity,
+		Metadata:   params.Metadata,
+	}
+	if params.PaymentMethodID != nil {
+		sub.PaymentMethodID = *params.PaymentMethodID
+	}
+	writeJSON(w, http.StatusCreated, s.seedSubscription(sub))
+}
+
+func (s *Server) listSubscriptions(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	var subs []*tedo.Subscription
+	for _, sub := range s.subscriptions {
+		sub = s.findSubscription(sub.ID)
+		if customerID := query.Get("customer_id"); customerID != "" && sub.CustomerID != customerID {
+			continue
+		}
+		if status := query.Get("status"); status != "" && sub.Status != status {
+			continue
+		}
+		if planKey := query.Get("plan_key"); planKey != "" && sub.PlanKey != planKey {
+			continue
+		}
+		if after, ok := parseTime(query.Get("current_period_end_gte")); ok && sub.CurrentPeriodEnd.Before(after) {
+			continue
+		}
+		if before, ok := parseTime(query.Get("current_period_end_lt")); ok && !sub.CurrentPeriodEnd.Before(before) {
+			continue
+		}
+		subs = append(subs, sub)
+	}
+
+	start, end, next := paginate(r, len(subs))
+	list := tedo.SubscriptionList{Subscriptions: []tedo.Subscription{}, Total: len(subs), NextCursor: next}
+	for _, sub := range subs[start:end] {
+		list.Subscriptions = append(list.Subscriptions, *sub)
+	}
+	writeJSON(w, http.StatusOK, list)
+}
+
+// parseTime parses an RFC 3339 query value, reporting false if it is
+// empty or malformed.
+func parseTime(value string) (time.Time, bool) {
+	t, err := time.Parse(time.RFC3339Nano, value)
+	return t, err == nil
+}
+
+func (s *Server) getSubscription(w http.ResponseWriter, id string) {
+	sub := s.findSubscription(id)
+	if sub == nil {
+		writeNotFound(w, "subscription")
+		return
+	}
+	writeJSON(w, http.StatusOK, sub)
+}
+
+func (s *Server) updateSubscription(w http.ResponseWriter, r *http.Request, id string) {
+	sub := s.findSubscription(id)
+	if sub == nil {
+		writeNotFound(w, "subscription")
+		return
+	}
+	var params tedo.UpdateSubscriptionParams
+	if !decode(w, r, &params) {
+		return
+	}
+	switch params.ProrationBehavior {
+	case "", tedo.ProrationCreateProrations, tedo.ProrationNone, tedo.ProrationAlwaysInvoice, tedo.ProrationCreditBalance:
+	default:
+		writeError(w, http.StatusBadRequest, "validation_error", "unknown proration_behavior", "proration_behavior")
+		return
+	}
+	if !checkEffectiveAt(w, sub, params.EffectiveAt) {
+		return
+	}
+
+	if params.PriceID != nil || params.PlanKey != nil || params.PriceKey != nil {
+		deref := func(v *string) string {
+			if v == nil {
+				return ""
+			}
+			return *v
+		}
+		price := s.resolvePrice(deref(params.PriceID), deref(params.PlanKey), deref(params.PriceKey))
+		if price == nil {
+			writeError(w, http.StatusBadRequest, "validation_error", "unknown price", "price_id")
+			return
+		}
+		sub.PriceID = price.ID
+		sub.PriceKey = price.Key
+		sub.PlanKey = ""
+		if plan := s.findPlan(price.PlanID); plan != nil {
+			sub.PlanKey = plan.Key
+		}
+	}
+	if params.Quantity != nil {
+		sub.Quantity = *params.Quantity
+	}
+	if params.Metadata != nil {
+		sub.Metadata = params.Metadata
+	}
+	writeJSON(w, http.StatusOK, sub)
+}
+
+// periodStart returns the start of sub's current period, which the fake
+// takes to be a month.
+func periodStart(sub *tedo.Subscription) time.Time {
+	return sub.CurrentPeriodEnd.AddDate(0, -1, 0)
+}
+
+// checkEffectiveAt rejects an effective date before the start of sub's
+// current period, as the API does, writing the error to w. It reports
+// whether the date is acceptable.
+func checkEffectiveAt(w http.ResponseWriter, sub *tedo.Subscription, effectiveAt *time.Time) bool {
+	if effectiveAt != nil && effectiveAt.Before(periodStart(sub)) {
+		writeError(w, http.StatusBadRequest, "validation_error", "effective_at is before the current period", "effective_at")
+		return false
+	}
+	return true
+}
+
+func (s *Server) pauseSubscription(w http.ResponseWriter, r *http.Request, id string) {
+	sub := s.findSubscription(id)
+	if sub == nil {
+		writeNotFound(w, "subscription")
+		return
+	}
+	var params tedo.PauseSubscriptionParams
+	if !decode(w, r, &params) {
+		return
+	}
+	if !tedo.CanTransition(tedo.SubscriptionStatus(sub.Status), tedo.SubscriptionStatusPaused) {
+		writeError(w, http.StatusConflict, "conflict", "a "+sub.Status+" subscription can't be paused", "")
+		return
+	}
+	pausedAt := now()
+	sub.Status = string(tedo.SubscriptionStatusPaused)
+	sub.PausedAt = &pausedAt
+	sub.PauseUsage = params.PauseUsage
+	sub.ResumesAt = nil
+	if params.ResumesAt != nil {
+		sub.ResumesAt = &tedo.Time{Time: params.ResumesAt.UTC().Truncate(time.Second)}
+	}
+	writeJSON(w, http.StatusOK, sub)
+}
+
+func (s *Server) resumeSubscription(w http.ResponseWriter, id string) {
+	sub := s.findSubscription(id)
+	if sub == nil {
+		writeNotFound(w, "subscription")
+		return
+	}
+	if sub.Status != string(tedo.SubscriptionStatusPaused) {
+		writeError(w, http.StatusConflict, "conflict", "a "+sub.Status+" subscription can't be resumed", "")
+		return
+	}
+	resume(sub)
+	writeJSON(w, http.StatusOK, sub)
+}
+
+// resume reactivates a paused subscription.
+func resume(sub *tedo.Subscription) {
+	sub.Status = string(tedo.SubscriptionStatusActive)
+	sub.PausedAt = nil
+	sub.PauseUsage = false
+	sub.ResumesAt = nil
+}
+
+func (s *Server) cancelSubscription(w http.ResponseWriter, r *http.Request, id string) {
+	sub := s.findSubscription(id)
+	if sub == nil {
+		writeNotFound(w, "subscription")
+		return
+	}
+	if sub.Status != string(tedo.SubscriptionStatusCanceled) {
+		query := r.URL.Query()
+		if reason := query.Get("reason"); reason != "" {
+			sub.CancellationReason = reason
+		}
+		if query.Get("at_period_end") == "true" {
+			sub.CancelAtPeriodEnd = true
+		} else {
+			cancel(sub, now())
+		}
+	}
+	writeJSON(w, http.StatusOK, sub)
+}
+
+// previewSubscriptionChange prorates by the time left in the current
+// period: a credit for the current price and a charge for the new one.
+func (s *Server) previewSubscriptionChange(w http.ResponseWriter, r *http.Request, id string) {
+	sub := s.findSubscription(id)
+	if sub == nil {
+		writeNotFound(w, "subscription")
+		return
+	}
+	var params tedo.PreviewSubscriptionChangeParams
+	if !decode(w, r, &params) {
+		return
+	}
+	if !checkEffectiveAt(w, sub, params.EffectiveAt) {
+		return
+	}
+	current := s.findPrice(sub.PriceID)
+	target := current
+	if params.PriceID != "" || params.PlanKey != "" {
+		target = s.resolvePrice(params.PriceID, params.PlanKey, params.PriceKey)
+	}
+	if current == nil || target == nil {
+		writeError(w, http.StatusBadRequest, "validation_error", "unknown price", "price_id")
+		return
+	}
+	quantity := max(sub.Quantity, 1)
+	newQuantity := quantity
+	if params.Quantity > 0 {
+		newQuantity = params.Quantity
+	}
+
+	prorationDate := now()
+	if params.EffectiveAt != nil {
+		prorationDate = tedo.Time{Time: params.EffectiveAt.UTC().Truncate(time.Second)}
+	}
+	periodEnd := sub.CurrentPeriodEnd.Time
+	periodStart := periodStart(sub)
+	left := 0.0
+	if periodEnd.After(prorationDate.Time) {
+		left = float64(periodEnd.Sub(prorationDate.Time)) / float64(periodEnd.Sub(periodStart))
+	}
+	prorate := func(amount int) int {
+		return int(math.Round(float64(amount) * left))
+	}
+
+	currentCost, err := current.CostFor(quantity)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "validation_error", err.Error(), "quantity")
+		return
+	}
+	targetCost, err := target.CostFor(newQuantity)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "validation_error", err.Error(), "quantity")
+		return
+	}
+
+	credit := tedo.SubscriptionChangeLineItem{
+		Description: "Unused time on " + current.Key,
+		Amount:      -prorate(currentCost.Amount),
+		Quantity:    quantity,
+		PriceID:     current.ID,
+		Proration:   true,
+	}
+	charge := tedo.SubscriptionChangeLineItem{
+		Description: "Remaining time on " + target.Key,
+		Amount:      prorate(targetCost.Amount),
+		Quantity:    newQuantity,
+		PriceID:     target.ID,
+		Proration:   true,
+	}
+	writeJSON(w, http.StatusOK, tedo.SubscriptionChangePreview{
+		AmountDueNow:  credit.Amount + charge.Amount,
+		GrossAmount:   charge.Amount,
+		NetAmount:     credit.Amount + charge.Amount,
+		Rounding:      tedo.RoundingHalfUp,
+		Currency:      target.Currency,
+		ProrationDate: prorationDate,
+		LineItems:     []tedo.SubscriptionChangeLineItem{credit, charge},
+	})
+}
+
+func (s *Server) reactivateSubscription(w http.ResponseWriter, id string) {
+	sub := s.findSubscription(id)
+	if sub == nil {
+		writeNotFound(w, "subscription")
+		return
+	}
+	if sub.Status == string(tedo.SubscriptionStatusCanceled) {
+		if sub.ReactivationDeadline == nil || !time.Now().Before(sub.ReactivationDeadline.Time) {
+			writeError(w, http.StatusConflict, "reactivation_expired", "the reactivation window has passed", "")
+			return
+		}
+		sub.Status = string(tedo.SubscriptionStatusActive)
+		sub.CanceledAt = nil
+		sub.ReactivationDeadline = nil
+	}
+	sub.CancelAtPeriodEnd = false
+	sub.CancellationReason = ""
+	writeJSON(w, http.StatusOK, sub)
+}
+
+// cancel cancels sub as of canceledAt.
+func cancel(sub *tedo.Subscription, canceledAt tedo.Time) {
+	sub.Status = string(tedo.SubscriptionStatusCanceled)
+	sub.CancelAtPeriodEnd = false
+	sub.CanceledAt = &canceledAt
+	sub.ReactivationDeadline = &tedo.Time{Time: canceledAt.Add(ReactivationWindow)}
+}
+
+// resolvePrice finds a price by ID, or else by plan key and optional price
+// key.
+func (s *Server) resolvePrice(priceID, planKey, priceKey string) *tedo.Price {
+	if price := s.findPrice(priceID); price != nil {
+		return price
+	}
+	if planKey == "" {
+		return nil
+	}
+	for _, p := range s.prices {
+		plan := s.findPlan(p.PlanID)
+		if plan != nil && plan.Key == planKey && (priceKey == "" || p.Key == priceKey) {
+			return p
+		}
+	}
+	return nil
+}
+
+// findSubscription returns the subscription with the given ID, first
+// applying a scheduled resume or cancellation that has passed.
+func (s *Server) findSubscription(id string) *tedo.Subscription {
+	for _, sub := range s.subscriptions {
+		if sub.ID == id {
+			if sub.ResumesAt != nil && !sub.ResumesAt.After(time.Now()) {
+				resume(sub)
+			}
+			if sub.CancelAtPeriodEnd && !sub.CurrentPeriodEnd.After(time.Now()) {
+				cancel(sub, sub.CurrentPeriodEnd)
+			}
+			return sub
+		}
+	}
+	return nil
+}
+
+// ==== ENTITLEMENT CHECKS ====
+
+func (s *Server) checkEntitlement(w http.ResponseWriter, r *http.Request) {
+	var params tedo.CheckEntitlementParams
+	if !decode(w, r, &params) {
+		return
+	}
+	if s.findCustomer(params.CustomerID) == nil {
+		writeNotFound(w, "customer")
+		return
+	}
+
+	var subs []*tedo.Subscription
+	for _, sub := range s.subscriptions {
+		if sub.CustomerID == params.CustomerID {
+			subs = append(subs, sub)
+		}
+	}
+	writeJSON(w, http.StatusOK, s.check(subs, params.EntitlementKey))
+}
+
+func (s *Server) checkSubscriptionEntitlement(w http.ResponseWriter, id, key string) {
+	sub := s.findSubscription(id)
+	if sub == nil {
+		writeNotFound(w, "subscription")
+		return
+	}
+	writeJSON(w, http.StatusOK, s.check([]*tedo.Subscription{sub}, key))
+}
+
+// check resolves key against the plans of the live subscriptions in subs.
+func (s *Server) check(subs []*tedo.Subscription, key string) tedo.EntitlementCheck {
+	result := tedo.EntitlementCheck{
+		EntitlementKey: key,
+		Source:         tedo.EntitlementSourceDefault,
+	}
+	for _, sub := range subs {
+		if !isLive(sub.Status) {
+			continue
+		}
+		price := s.findPrice(sub.PriceID)
+		if price == nil {
+			continue
+		}
+		plan := s.findPlan(price.PlanID)
+		for _, e := range s.entitlements {
+			if e.PlanID != price.PlanID || e.Key != key {
+				continue
+			}
+			result.Source = tedo.EntitlementSourcePlan
+			if plan != nil {
+				result.PlanName, result.PlanKey = plan.Name, plan.Key
+			}
+			switch {
+			case e.ValueBool != nil:
+				result.HasAccess, result.Value = *e.ValueBool, *e.ValueBool
+			case e.ValueInt != nil:
+				result.HasAccess, result.Value = *e.ValueInt != 0, *e.ValueInt
+			}
+			return result
+		}
+	}
+	return result
+}
+
+// isLive reports whether a subscription in status grants its entitlements.
+func isLive(status string) bool {
+	switch tedo.SubscriptionStatus(status) {
+	case tedo.SubscriptionStatusActive, tedo.SubscriptionStatusTrialing, tedo.SubscriptionStatusPastDue:
+		return true
+	}
+	return false
+}
+
+// ==== USAGE ====
+
+func (s *Server) recordUsage(w http.ResponseWriter, r *http.Request) {
+	var params tedo.RecordUsageParams
+	if !decode(w, r, &params) {
+		return
+	}
+	sub := s.findSubscription(params.SubscriptionID)
+	if sub == nil {
+		writeNotFound(w, "subscription")
+		return
+	}
+	if sub.UsagePaused() {
+		writeError(w, http.StatusConflict, "subscription_paused", "usage is paused for this subscription", "")
+		return
+	}
+
+	key := params.IdempotencyKey
+	if key == "" {
+		key = r.Header.Get("Idempotency-Key")
+	}
+	if key != "" {
+		for _, record := range s.usage {
+			if record.SubscriptionID == sub.ID && record.IdempotencyKey == key {
+				writeJSON(w, http.StatusOK, record)
+				return
+			}
+		}
+	}
+
+	record := &tedo.UsageRecord{
+		CustomerID:     sub.CustomerID,
+		SubscriptionID: sub.ID,
+		ProductKey:     params.ProductKey,
+		Quantity:       params.Quantity,
+		IdempotencyKey: key,
+		Metadata:       params.Metadata,
+	}
+	s.fill(&record.ID, "usage", &record.CreatedAt.Time)
+	record.Timestamp = record.CreatedAt
+	if params.Timestamp != nil {
+		record.Timestamp = tedo.Time{Time: *params.Timestamp}
+	}
+	s.usage = append(s.usage, record)
+	writeJSON(w, http.StatusCreated, record)
+}
+
+func (s *Server) usageSummary(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	sub := s.findSubscription(query.Get("subscription_id"))
+	if sub == nil {
+		writeNotFound(w, "subscription")
+		return
+	}
+
+	today := time.Now().UTC()
+	start := time.Date(today.Year(), today.Month(), 1, 0, 0, 0, 0, time.UTC)
+	summary := tedo.UsageSummary{
+		SubscriptionID: sub.ID,
+		ProductKey:     query.Get("product_key"),
+		PeriodStart:    start.Format(time.RFC3339),
+		PeriodEnd:      start.AddDate(0, 1, 0).Format(time.RFC3339),
+	}
+	for _, record := range s.usage {
+		if record.SubscriptionID != sub.ID {
+			continue
+		}
+		if summary.ProductKey != "" && record.ProductKey != summary.ProductKey {
+			continue
+		}
+		summary.TotalUsage += record.Quantity
+		summary.RecordCount++
+	}
+	writeJSON(w, http.StatusOK, summary)
+}
+
+// ==== ENCODING ====
+
+// paginate returns the bounds of the requested page of n items and the
+// cursor of the next page, empty on the last. Cursors are offsets, and the
+// page size defaults to 20.
+func paginate(r *http.Request, n int) (start, end int, next string) {
+	query := r.URL.Query()
+	start, _ = strconv.Atoi(query.Get("cursor"))
+	limit, _ := strconv.Atoi(query.Get("limit"))
+	if limit <= 0 {
+		limit = 20
+	}
+	start = min(max(start, 0), n)
+	end = min(start+limit, n)
+	if end < n {
+		next = strconv.Itoa(end)
+	}
+	return start, end, next
+}
+
+// decode reads the JSON request body into v, writing a validation error and
+// returning false if it is malformed.
+func decode(w http.ResponseWriter, r *http.Request, v any) bool {
+	if err := json.NewDecoder(r.Body).Decode(v); err != nil {
+		writeError(w, http.StatusBadRequest, "validation_error", "invalid JSON body: "+err.Error(), "")
+		return false
+	}
+	return true
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, code, message, field string) {
+	writeJSON(w, status, &tedo.Error{Code: code, Message: message, Field: field})
+}
+
+func writeNotFound(w http.ResponseWriter, resource string) {
+	writeError(w, http.StatusNotFound, "not_found", fmt.Sprintf("%s not found", resource), "")
+}
@@ -0,0 +1,791 @@
+package tedotest_test
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/tedo-ai/tedo-go"
+	"github.com/tedo-ai/tedo-go/tedotest"
+)
+
+func TestPlans(t *testing.T) {
+	ctx := context.Background()
+	billing := tedotest.NewClient(t).Billing
+
+	plan, err := billing.CreatePlan(ctx, &tedo.CreatePlanParams{Key: "pro", Name: "Pro"})
+	if err != nil {
+		t.Fatalf("CreatePlan: %v", err)
+	}
+	if !plan.IsActive || plan.ID == "" {
+		t.Errorf("created plan = %+v, want active with an ID", plan)
+	}
+	if _, err := billing.CreatePlan(ctx, &tedo.CreatePlanParams{Key: "pro", Name: "Pro"}); !tedo.IsConflict(err) {
+		t.Errorf("duplicate CreatePlan error = %v, want conflict", err)
+	}
+
+	name := "Professional"
+	if _, err := billing.UpdatePlan(ctx, plan.ID, &tedo.UpdatePlanParams{Name: &name}); err != nil {
+		t.Fatalf("UpdatePlan: %v", err)
+	}
+	got, err := billing.GetPlan(ctx, plan.ID)
+	if err != nil {
+		t.Fatalf("GetPlan: %v", err)
+	}
+	if got.Name != name || got.Key != "pro" {
+		t.Errorf("GetPlan = %+v, want renamed plan", got)
+	}
+
+	if err := billing.DeletePlan(ctx, plan.ID); err != nil {
+		t.Fatalf("DeletePlan: %v", err)
+	}
+	active := true
+	list, err := billing.ListPlans(ctx, &tedo.ListPlansParams{IsActive: &active})
+	if err != nil {
+		t.Fatalf("ListPlans: %v", err)
+	}
+	if len(list.Plans) != 0 {
+		t.Errorf("active plans after delete = %d, want 0", len(list.Plans))
+	}
+	if _, err := billing.GetPlan(ctx, "plan_missing"); !tedo.IsNotFound(err) {
+		t.Errorf("GetPlan(missing) error = %v, want not found", err)
+	}
+}
+
+func TestPrices(t *testing.T) {
+	ctx := context.Background()
+	srv := tedotest.NewServer(t)
+	billing := srv.Client().Billing
+	plan := srv.SeedPlan(tedo.Plan{Key: "pro", Name: "Pro"})
+
+	price, err := billing.CreatePrice(ctx, plan.ID, &tedo.CreatePriceParams{Key: "yearly", Amount: 29000, Interval: "year"})
+	if err != nil {
+		t.Fatalf("CreatePrice: %v", err)
+	}
+	if price.PlanID != plan.ID || price.Currency != "eur" || price.IntervalCount != 1 {
+		t.Errorf("created price = %+v, want defaults filled in", price)
+	}
+
+	got, err := billing.GetPrice(ctx, plan.ID, price.ID)
+	if err != nil || got.Amount != 29000 {
+		t.Errorf("GetPrice = %+v, %v", got, err)
+	}
+	byKey, err := billing.GetPriceByKey(ctx, "pro", "yearly")
+	if err != nil || byKey.ID != price.ID {
+		t.Errorf("GetPriceByKey = %+v, %v; want %s", byKey, err, price.ID)
+	}
+
+	if err := billing.ArchivePrice(ctx, plan.ID, price.ID); err != nil {
+		t.Fatalf("ArchivePrice: %v", err)
+	}
+	list, err := billing.ListPrices(ctx, plan.ID)
+	if err != nil {
+		t.Fatalf("ListPrices: %v", err)
+	}
+	if len(list.Prices) != 0 {
+		t.Errorf("prices after archive = %d, want 0", len(list.Prices))
+	}
+}
+
+func TestEntitlements(t *testing.T) {
+	ctx := context.Background()
+	srv := tedotest.NewServer(t)
+	billing := srv.Client().Billing
+	plan := srv.SeedPlan(tedo.Plan{Key: "pro", Name: "Pro"})
+
+	seats := 5
+	entitlement, err := billing.CreateEntitlement(ctx, plan.ID, &tedo.CreateEntitlementParams{Key: "seats", ValueInt: &seats})
+	if err != nil {
+		t.Fatalf("CreateEntitlement: %v", err)
+	}
+	list, err := billing.ListEntitlements(ctx, plan.ID)
+	if err != nil {
+		t.Fatalf("ListEntitlements: %v", err)
+	}
+	if len(list.Entitlements) != 1 || *list.Entitlements[0].ValueInt != seats {
+		t.Errorf("ListEntitlements = %+v, want one with 5 seats", list.Entitlements)
+	}
+
+	if err := billing.ArchiveEntitlement(ctx, plan.ID, entitlement.ID); err != nil {
+		t.Fatalf("ArchiveEntitlement: %v", err)
+	}
+	list, err = billing.ListEntitlements(ctx, plan.ID)
+	if err != nil {
+		t.Fatalf("ListEntitlements: %v", err)
+	}
+	if len(list.Entitlements) != 0 {
+		t.Errorf("entitlements after archive = %d, want 0", len(list.Entitlements))
+	}
+}
+
+func TestCustomers(t *testing.T) {
+	ctx := context.Background()
+	billing := tedotest.NewClient(t).Billing
+
+	customer, err := billing.CreateCustomer(ctx, &tedo.CreateCustomerParams{
+		Email:      "jane@example.com",
+		ExternalID: "user:1",
+		Metadata:   map[string]string{"team": "a"},
+	})
+	if err != nil {
+		t.Fatalf("CreateCustomer: %v", err)
+	}
+
+	name := "Jane"
+	if _, err := billing.UpdateCustomer(ctx, customer.ID, &tedo.UpdateCustomerParams{Name: &name}); err != nil {
+		t.Fatalf("UpdateCustomer: %v", err)
+	}
+	got, err := billing.GetCustomer(ctx, customer.ID)
+	if err != nil {
+		t.Fatalf("GetCustomer: %v", err)
+	}
+	if got.Name != name || got.Email != "jane@example.com" || got.Metadata["team"] != "a" {
+		t.Errorf("GetCustomer = %+v", got)
+	}
+	if byEmail, err := billing.GetCustomerByEmail(ctx, "jane@example.com"); err != nil || byEmail.ID != customer.ID {
+		t.Errorf("GetCustomerByEmail = %+v, %v", byEmail, err)
+	}
+	if byUser, err := billing.GetCustomerForUser(ctx, 1); err != nil || byUser.ID != customer.ID {
+		t.Errorf("GetCustomerForUser = %+v, %v", byUser, err)
+	}
+
+	if err := billing.DeleteCustomer(ctx, customer.ID); err != nil {
+		t.Fatalf("DeleteCustomer: %v", err)
+	}
+	if _, err := billing.GetCustomer(ctx, customer.ID); !tedo.IsNotFound(err) {
+		t.Errorf("GetCustomer after delete error = %v, want not found", err)
+	}
+}
+
+func TestSubscriptions(t *testing.T) {
+	ctx := context.Background()
+	srv := tedotest.NewServer(t)
+	billing := srv.Client().Billing
+	plan := srv.SeedPlan(tedo.Plan{
+		Key:    "pro",
+		Name:   "Pro",
+		Prices: []tedo.Price{{Key: "monthly", Amount: 2900}, {Key: "yearly", Amount: 29000, Interval: "year"}},
+	})
+	customer := srv.SeedCustomer(tedo.Customer{Email: "jane@example.com"})
+
+	sub, err := billing.CreateSubscription(ctx, &tedo.CreateSubscriptionParams{CustomerID: customer.ID, PriceID: plan.Prices[0].ID})
+	if err != nil {
+		t.Fatalf("CreateSubscription: %v", err)
+	}
+	if sub.Status != string(tedo.SubscriptionStatusActive) || sub.PlanKey != "pro" || sub.PriceKey != "monthly" {
+		t.Errorf("created subscription = %+v", sub)
+	}
+
+	yearly := plan.Prices[1].ID
+	if _, err := billing.UpdateSubscription(ctx, sub.ID, &tedo.UpdateSubscriptionParams{PriceID: &yearly}); err != nil {
+		t.Fatalf("UpdateSubscription: %v", err)
+	}
+	got, err := billing.GetSubscription(ctx, sub.ID)
+	if err != nil {
+		t.Fatalf("GetSubscription: %v", err)
+	}
+	if got.PriceID != yearly || got.PriceKey != "yearly" {
+		t.Errorf("GetSubscription = %+v, want yearly price", got)
+	}
+
+	list, err := billing.ListSubscriptionsForCustomer(ctx, customer.ID)
+	if err != nil || len(list) != 1 {
+		t.Fatalf("ListSubscriptionsForCustomer = %d, %v; want 1", len(list), err)
+	}
+
+	canceled, err := billing.CancelSubscription(ctx, sub.ID, nil)
+	if err != nil {
+		t.Fatalf("CancelSubscription: %v", err)
+	}
+	if canceled.Status != string(tedo.SubscriptionStatusCanceled) {
+		t.Errorf("status after cancel = %s", canceled.Status)
+	}
+}
+
+func TestUsage(t *testing.T) {
+	ctx := context.Background()
+	srv := tedotest.NewServer(t)
+	billing := srv.Client().Billing
+	sub := srv.SeedSubscription(tedo.Subscription{CustomerID: "cus_1"})
+
+	for _, quantity := range []int{3, 4} {
+		if _, err := billing.RecordUsage(ctx, &tedo.RecordUsageParams{SubscriptionID: sub.ID, ProductKey: "api_calls", Quantity: quantity}); err != nil {
+			t.Fatalf("RecordUsage: %v", err)
+		}
+	}
+	summary, err := billing.GetUsageSummaryByKey(ctx, sub.ID, "api_calls")
+	if err != nil {
+		t.Fatalf("GetUsageSummary: %v", err)
+	}
+	if summary.TotalUsage != 7 || summary.RecordCount != 2 {
+		t.Errorf("summary = %+v, want 7 over 2 records", summary)
+	}
+	if records := srv.UsageRecords(); len(records) != 2 {
+		t.Errorf("UsageRecords = %d, want 2", len(records))
+	}
+}
+
+func TestEntitlementChecks(t *testing.T) {
+	ctx := context.Background()
+	srv := tedotest.NewServer(t)
+	billing := srv.Client().Billing
+	seats := 5
+	plan := srv.SeedPlan(tedo.Plan{
+		Key:          "pro",
+		Name:         "Pro",
+		Prices:       []tedo.Price{{Key: "monthly", Amount: 2900}},
+		Entitlements: []tedo.Entitlement{{Key: "seats", ValueInt: &seats}},
+	})
+	customer := srv.SeedCustomer(tedo.Customer{Email: "jane@example.com"})
+	sub := srv.SeedSubscription(tedo.Subscription{CustomerID: customer.ID, PriceID: plan.Prices[0].ID})
+
+	check, err := billing.CheckEntitlementByKey(ctx, customer.ID, "seats")
+	if err != nil {
+		t.Fatalf("CheckEntitlementByKey: %v", err)
+	}
+	if n, ok := check.IntValue(); !check.HasAccess || !ok || n != seats {
+		t.Errorf("check = %+v, want 5 seats", check)
+	}
+	check, err = billing.CheckSubscriptionEntitlement(ctx, sub.ID, "exports")
+	if err != nil {
+		t.Fatalf("CheckSubscriptionEntitlement: %v", err)
+	}
+	if check.HasAccess {
+		t.Errorf("access to an entitlement the plan lacks")
+	}
+}
+
+func TestSeedPlanIsActive(t *testing.T) {
+	srv := tedotest.NewServer(t)
+	plan := srv.SeedPlan(tedo.Plan{Key: "pro", Name: "Pro"})
+	if !plan.IsActive {
+		t.Errorf("seeded plan is inactive")
+	}
+
+	active := true
+	list, err := srv.Client().Billing.ListPlans(context.Background(), &tedo.ListPlansParams{IsActive: &active})
+	if err != nil {
+		t.Fatalf("ListPlans: %v", err)
+	}
+	if len(list.Plans) != 1 {
+		t.Errorf("active plans = %d, want 1", len(list.Plans))
+	}
+}
+
+func TestPauseUsage(t *testing.T) {
+	ctx := context.Background()
+	srv := tedotest.NewServer(t)
+	billing := srv.Client().Billing
+	sub := srv.SeedSubscription(tedo.Subscription{CustomerID: "cus_1"})
+	record := &tedo.RecordUsageParams{SubscriptionID: sub.ID, ProductKey: "api_calls", Quantity: 1}
+
+	paused, err := billing.PauseSubscription(ctx, sub.ID, &tedo.PauseSubscriptionParams{PauseUsage: true})
+	if err != nil {
+		t.Fatalf("PauseSubscription: %v", err)
+	}
+	if !paused.UsagePaused() {
+		t.Errorf("paused subscription = %+v, want usage paused", paused)
+	}
+	if _, err := billing.RecordUsage(ctx, record); !tedo.IsSubscriptionPaused(err) || !tedo.IsConflict(err) {
+		t.Errorf("RecordUsage while paused error = %v, want subscription paused", err)
+	}
+
+	resumed, err := billing.ResumeSubscription(ctx, sub.ID)
+	if err != nil {
+		t.Fatalf("ResumeSubscription: %v", err)
+	}
+	if resumed.UsagePaused() {
+		t.Errorf("resumed subscription still has usage paused")
+	}
+	if _, err := billing.RecordUsage(ctx, record); err != nil {
+		t.Errorf("RecordUsage after resume: %v", err)
+	}
+
+	if _, err := billing.PauseSubscription(ctx, sub.ID, nil); err != nil {
+		t.Fatalf("PauseSubscription: %v", err)
+	}
+	if _, err := billing.RecordUsage(ctx, record); err != nil {
+		t.Errorf("RecordUsage while paused without PauseUsage: %v", err)
+	}
+}
+
+func TestReactivation(t *testing.T) {
+	ctx := context.Background()
+	srv := tedotest.NewServer(t)
+	billing := srv.Client().Billing
+	sub := srv.SeedSubscription(tedo.Subscription{CustomerID: "cus_1"})
+
+	canceled, err := billing.CancelSubscription(ctx, sub.ID, nil)
+	if err != nil {
+		t.Fatalf("CancelSubscription: %v", err)
+	}
+	if canceled.ReactivationDeadline == nil || !canceled.CanReactivate() {
+		t.Fatalf("canceled subscription = %+v, want reactivatable", canceled)
+	}
+	reactivated, err := billing.ReactivateSubscription(ctx, sub.ID)
+	if err != nil {
+		t.Fatalf("ReactivateSubscription: %v", err)
+	}
+	if reactivated.Status != string(tedo.SubscriptionStatusActive) || reactivated.CanceledAt != nil {
+		t.Errorf("reactivated subscription = %+v, want active", reactivated)
+	}
+
+	expired := srv.SeedSubscription(tedo.Subscription{
+		CustomerID:           "cus_1",
+		Status:               string(tedo.SubscriptionStatusCanceled),
+		ReactivationDeadline: &tedo.Time{Time: time.Now().Add(-time.Hour)},
+	})
+	if expired.CanReactivate() {
+		t.Errorf("CanReactivate past the deadline")
+	}
+	_, err = billing.ReactivateSubscription(ctx, expired.ID)
+	if !errors.Is(err, tedo.ErrReactivationExpired) || !tedo.IsConflict(err) {
+		t.Errorf("ReactivateSubscription past the deadline error = %v, want ErrReactivationExpired", err)
+	}
+}
+
+func TestListSubscriptionsByPeriodEnd(t *testing.T) {
+	srv := tedotest.NewServer(t)
+	start := time.Date(2026, 11, 1, 0, 0, 0, 0, time.UTC)
+	for _, days := range []int{-1, 0, 6, 7} {
+		srv.SeedSubscription(tedo.Subscription{CustomerID: "cus_1", CurrentPeriodEnd: tedo.Time{Time: start.AddDate(0, 0, days)}})
+	}
+
+	list, err := srv.Client().Billing.ListSubscriptions(context.Background(), &tedo.ListSubscriptionsParams{
+		PeriodEndAfter:  start,
+		PeriodEndBefore: start.AddDate(0, 0, 7),
+	})
+	if err != nil {
+		t.Fatalf("ListSubscriptions: %v", err)
+	}
+	if len(list.Subscriptions) != 2 {
+		t.Errorf("subscriptions in window = %d, want 2", len(list.Subscriptions))
+	}
+}
+
+func TestPreviewSubscriptionChangeAmounts(t *testing.T) {
+	srv := tedotest.NewServer(t)
+	plan := srv.SeedPlan(tedo.Plan{
+		Key:    "pro",
+		Name:   "Pro",
+		Prices: []tedo.Price{{Key: "monthly", Amount: 999}, {Key: "team", Amount: 2999}},
+	})
+	sub := srv.SeedSubscription(tedo.Subscription{
+		CustomerID:       "cus_1",
+		PriceID:          plan.Prices[0].ID,
+		CurrentPeriodEnd: tedo.Time{Time: time.Now().Add(11 * 24 * time.Hour)},
+	})
+
+	preview, err := srv.Client().Billing.PreviewSubscriptionChange(context.Background(), sub.ID, &tedo.PreviewSubscriptionChangeParams{PriceID: plan.Prices[1].ID})
+	if err != nil {
+		t.Fatalf("PreviewSubscriptionChange: %v", err)
+	}
+	gross, net := 0, 0
+	for _, item := range preview.LineItems {
+		if item.Amount > 0 {
+			gross += item.Amount
+		}
+		net += item.Amount
+	}
+	if preview.GrossAmount != gross || preview.NetAmount != net || preview.AmountDueNow != net {
+		t.Errorf("preview = %+v, want gross %d and net %d", preview, gross, net)
+	}
+	if preview.Rounding != tedo.RoundingHalfUp {
+		t.Errorf("Rounding = %q, want %q", preview.Rounding, tedo.RoundingHalfUp)
+	}
+	if got := preview.Net(); got != (tedo.Money{Amount: net, Currency: "eur"}) {
+		t.Errorf("Net() = %v", got)
+	}
+}
+
+func TestDowngradeToFree(t *testing.T) {
+	ctx := context.Background()
+	srv := tedotest.NewServer(t)
+	billing := srv.Client().Billing
+	pro := srv.SeedPlan(tedo.Plan{Key: "pro", Name: "Pro", Prices: []tedo.Price{{Key: "monthly", Amount: 2900}}})
+	sub := srv.SeedSubscription(tedo.Subscription{CustomerID: "cus_1", PriceID: pro.Prices[0].ID})
+
+	if _, err := billing.DowngradeToFree(ctx, sub.ID); !tedo.IsNotFound(err) {
+		t.Errorf("DowngradeToFree without a free plan error = %v, want not found", err)
+	}
+
+	free := srv.SeedPlan(tedo.Plan{Key: tedo.FreePlanKey, Name: "Free", Prices: []tedo.Price{{Key: tedo.FreePriceKey}}})
+	downgraded, err := billing.DowngradeToFree(ctx, sub.ID)
+	if err != nil {
+		t.Fatalf("DowngradeToFree: %v", err)
+	}
+	if downgraded.PriceID != free.Prices[0].ID || downgraded.PlanKey != tedo.FreePlanKey {
+		t.Errorf("downgraded subscription = %+v, want the free price", downgraded)
+	}
+}
+
+func TestListCustomersQuery(t *testing.T) {
+	srv := tedotest.NewServer(t)
+	old := srv.SeedCustomer(tedo.Customer{Email: "jane@example.com", CreatedAt: tedo.Time{Time: time.Now().Add(-48 * time.Hour)}})
+	recent := srv.SeedCustomer(tedo.Customer{Email: "jane@example.com"})
+	srv.SeedCustomer(tedo.Customer{Email: "joe@example.com"})
+
+	query := tedo.NewCustomerQuery().Email("jane@example.com")
+	list, err := srv.Client().Billing.ListCustomers(context.Background(), query.UpdatedSince(time.Now().Add(-time.Hour)).Params())
+	if err != nil {
+		t.Fatalf("ListCustomers: %v", err)
+	}
+	if len(list.Customers) != 1 || list.Customers[0].ID != recent.ID {
+		t.Errorf("customers = %+v, want only %s", list.Customers, recent.ID)
+	}
+	list, err = srv.Client().Billing.ListCustomers(context.Background(), query.Params())
+	if err != nil || len(list.Customers) != 2 || list.Customers[0].ID != old.ID {
+		t.Errorf("customers by email = %+v, %v; want both of jane's", list, err)
+	}
+}
+
+func TestUsageMetadata(t *testing.T) {
+	ctx := context.Background()
+	srv := tedotest.NewServer(t)
+	billing := srv.Client().Billing
+	sub := srv.SeedSubscription(tedo.Subscription{CustomerID: "cus_1"})
+	metadata := map[string]string{"feature": "export", "region": "eu-west"}
+
+	record, err := billing.RecordUsage(ctx, &tedo.RecordUsageParams{SubscriptionID: sub.ID, ProductKey: "api_calls", Quantity: 1, Metadata: metadata})
+	if err != nil {
+		t.Fatalf("RecordUsage: %v", err)
+	}
+	if !maps.Equal(record.Metadata, metadata) {
+		t.Errorf("returned metadata = %v, want %v", record.Metadata, metadata)
+	}
+	plain, err := billing.RecordUsage(ctx, &tedo.RecordUsageParams{SubscriptionID: sub.ID, ProductKey: "api_calls", Quantity: 1})
+	if err != nil {
+		t.Fatalf("RecordUsage: %v", err)
+	}
+	if plain.Metadata != nil {
+		t.Errorf("metadata without any set = %v, want nil", plain.Metadata)
+	}
+
+	stored := srv.UsageRecords()
+	if len(stored) != 2 || !maps.Equal(stored[0].Metadata, metadata) || stored[1].Metadata != nil {
+		t.Errorf("stored records = %+v, want the metadata on the first only", stored)
+	}
+}
+
+func TestListPlansPagination(t *testing.T) {
+	ctx := context.Background()
+	srv := tedotest.NewServer(t)
+	billing := srv.Client().Billing
+	for _, key := range []string{"free", "basic", "pro", "team", "legacy"} {
+		srv.SeedPlan(tedo.Plan{Key: key, Name: key})
+	}
+	list, err := billing.ListPlans(ctx, &tedo.ListPlansParams{Key: "legacy"})
+	if err != nil || len(list.Plans) != 1 {
+		t.Fatalf("ListPlans by key = %+v, %v; want one plan", list, err)
+	}
+	if err := billing.DeletePlan(ctx, list.Plans[0].ID); err != nil {
+		t.Fatalf("DeletePlan: %v", err)
+	}
+
+	var keys []string
+	params := &tedo.ListPlansParams{Limit: 2}
+	for pages := 1; ; pages++ {
+		list, err := billing.ListPlans(ctx, params)
+		if err != nil {
+			t.Fatalf("ListPlans page %d: %v", pages, err)
+		}
+		if len(list.Plans) > 2 || list.Total != 5 {
+			t.Errorf("page %d has %d of %d plans, want at most 2 of 5", pages, len(list.Plans), list.Total)
+		}
+		for _, p := range list.Plans {
+			keys = append(keys, p.Key)
+		}
+		if list.NextCursor == "" {
+			if pages != 3 {
+				t.Errorf("got %d pages, want 3", pages)
+			}
+			break
+		}
+		params.Cursor = list.NextCursor
+	}
+	if want := []string{"free", "basic", "pro", "team", "legacy"}; !slices.Equal(keys, want) {
+		t.Errorf("keys = %q, want %q", keys, want)
+	}
+
+	active, inactive := true, false
+	for _, tt := range []struct {
+		params *tedo.ListPlansParams
+		want   []string
+	}{
+		{&tedo.ListPlansParams{IsActive: &active}, []string{"free", "basic", "pro", "team"}},
+		{&tedo.ListPlansParams{IsActive: &inactive}, []string{"legacy"}},
+		{&tedo.ListPlansParams{Key: "pro"}, []string{"pro"}},
+		{&tedo.ListPlansParams{Key: "legacy", IsActive: &active}, nil},
+	} {
+		list, err := billing.ListPlans(ctx, tt.params)
+		if err != nil {
+			t.Fatalf("ListPlans(%+v): %v", tt.params, err)
+		}
+		var got []string
+		for _, p := range list.Plans {
+			got = append(got, p.Key)
+		}
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("ListPlans(%+v) = %q, want %q", tt.params, got, tt.want)
+		}
+	}
+}
+
+func TestUpsertCustomer(t *testing.T) {
+	ctx := context.Background()
+	billing := tedotest.NewClient(t).Billing
+
+	created, isNew, err := billing.UpsertCustomer(ctx, &tedo.UpsertCustomerParams{
+		ExternalID: "user:1",
+		Email:      "jane@example.com",
+		Metadata:   map[string]string{"team": "a"},
+	})
+	if err != nil || !isNew {
+		t.Fatalf("UpsertCustomer create = %v, %v; want a new customer", isNew, err)
+	}
+
+	updated, isNew, err := billing.UpsertCustomer(ctx, &tedo.UpsertCustomerParams{
+		ExternalID: "user:1",
+		Name:       "Jane",
+		Metadata:   map[string]string{"plan": "pro"},
+	})
+	if err != nil || isNew {
+		t.Fatalf("UpsertCustomer update = %v, %v; want an existing customer", isNew, err)
+	}
+	if updated.ID != created.ID || updated.Name != "Jane" || updated.Email != "jane@example.com" {
+		t.Errorf("updated customer = %+v", updated)
+	}
+	if want := map[string]string{"team": "a", "plan": "pro"}; !maps.Equal(updated.Metadata, want) {
+		t.Errorf("metadata = %v, want %v merged", updated.Metadata, want)
+	}
+
+	unchanged, isNew, err := billing.UpsertCustomer(ctx, &tedo.UpsertCustomerParams{ExternalID: "user:1", Name: "Jane"})
+	if err != nil || isNew || unchanged.ID != created.ID {
+		t.Errorf("UpsertCustomer without changes = %+v, %v, %v", unchanged, isNew, err)
+	}
+
+	if _, _, err := billing.UpsertCustomer(ctx, &tedo.UpsertCustomerParams{Email: "x@example.com"}); !tedo.IsValidationError(err) {
+		t.Errorf("UpsertCustomer without an external ID error = %v, want a validation error", err)
+	}
+}
+
+func TestUpdateSubscriptionPlanChanges(t *testing.T) {
+	ctx := context.Background()
+	srv := tedotest.NewServer(t)
+	billing := srv.Client().Billing
+	basic := srv.SeedPlan(tedo.Plan{Key: "basic", Name: "Basic", Prices: []tedo.Price{{Key: "monthly", Amount: 900}}})
+	srv.SeedPlan(tedo.Plan{Key: "pro", Name: "Pro", Prices: []tedo.Price{{Key: "monthly", Amount: 2900}}})
+	sub := srv.SeedSubscription(tedo.Subscription{CustomerID: "cus_1", PriceID: basic.Prices[0].ID, Quantity: 1})
+
+	pro := "pro"
+	upgraded, err := billing.UpdateSubscription(ctx, sub.ID, &tedo.UpdateSubscriptionParams{PlanKey: &pro, ProrationBehavior: tedo.ProrationAlwaysInvoice})
+	if err != nil {
+		t.Fatalf("upgrade: %v", err)
+	}
+	if upgraded.PlanKey != "pro" || upgraded.PriceKey != "monthly" {
+		t.Errorf("upgraded subscription = %+v, want pro", upgraded)
+	}
+
+	downgraded, err := billing.UpdateSubscription(ctx, sub.ID, &tedo.UpdateSubscriptionParams{PriceID: &basic.Prices[0].ID, ProrationBehavior: tedo.ProrationNone})
+	if err != nil {
+		t.Fatalf("downgrade: %v", err)
+	}
+	if downgraded.PlanKey != "basic" || downgraded.PriceID != basic.Prices[0].ID {
+		t.Errorf("downgraded subscription = %+v, want basic", downgraded)
+	}
+
+	credited, err := billing.UpdateSubscription(ctx, sub.ID, &tedo.UpdateSubscriptionParams{PriceID: &basic.Prices[0].ID, ProrationBehavior: tedo.ProrationCreditBalance})
+	if err != nil {
+		t.Fatalf("downgrade credited to the balance: %v", err)
+	}
+	if credited.PlanKey != "basic" {
+		t.Errorf("credited subscription = %+v, want basic", credited)
+	}
+
+	seats := 5
+	resized, err := billing.UpdateSubscription(ctx, sub.ID, &tedo.UpdateSubscriptionParams{Quantity: &seats})
+	if err != nil {
+		t.Fatalf("quantity change: %v", err)
+	}
+	if resized.Quantity != 5 || resized.PlanKey != "basic" {
+		t.Errorf("resized subscription = %+v, want 5 seats on basic", resized)
+	}
+
+	unknown := "enterprise"
+	if _, err := billing.UpdateSubscription(ctx, sub.ID, &tedo.UpdateSubscriptionParams{PlanKey: &unknown}); !tedo.IsValidationError(err) {
+		t.Errorf("change to an unknown plan error = %v, want a validation error", err)
+	}
+	if _, err := billing.UpdateSubscription(ctx, sub.ID, &tedo.UpdateSubscriptionParams{ProrationBehavior: "sometimes"}); !tedo.IsValidationError(err) {
+		t.Errorf("unknown proration behavior error = %v, want a validation error", err)
+	}
+}
+
+func TestPauseAndResume(t *testing.T) {
+	ctx := context.Background()
+	srv := tedotest.NewServer(t)
+	billing := srv.Client().Billing
+	sub := srv.SeedSubscription(tedo.Subscription{CustomerID: "cus_1"})
+
+	paused, err := billing.PauseSubscription(ctx, sub.ID, nil)
+	if err != nil {
+		t.Fatalf("PauseSubscription: %v", err)
+	}
+	if paused.Status != string(tedo.SubscriptionStatusPaused) || paused.PausedAt == nil || paused.ResumesAt != nil {
+		t.Errorf("paused subscription = %+v, want paused indefinitely", paused)
+	}
+	resumed, err := billing.ResumeSubscription(ctx, sub.ID)
+	if err != nil {
+		t.Fatalf("ResumeSubscription: %v", err)
+	}
+	if resumed.Status != string(tedo.SubscriptionStatusActive) || resumed.PausedAt != nil {
+		t.Errorf("resumed subscription = %+v, want active", resumed)
+	}
+	if _, err := billing.ResumeSubscription(ctx, sub.ID); !tedo.IsConflict(err) {
+		t.Errorf("resuming an active subscription error = %v, want conflict", err)
+	}
+
+	resumesAt := time.Now().Add(2 * time.Second)
+	scheduled, err := billing.PauseSubscription(ctx, sub.ID, &tedo.PauseSubscriptionParams{ResumesAt: &resumesAt})
+	if err != nil {
+		t.Fatalf("PauseSubscription with ResumesAt: %v", err)
+	}
+	if scheduled.ResumesAt == nil || !scheduled.ResumesAt.Equal(resumesAt.UTC().Truncate(time.Second)) {
+		t.Errorf("ResumesAt = %v, want %v", scheduled.ResumesAt, resumesAt)
+	}
+	time.Sleep(time.Until(resumesAt.Truncate(time.Second)) + 10*time.Millisecond)
+	got, err := billing.GetSubscription(ctx, sub.ID)
+	if err != nil {
+		t.Fatalf("GetSubscription: %v", err)
+	}
+	if got.Status != string(tedo.SubscriptionStatusActive) || got.ResumesAt != nil {
+		t.Errorf("subscription after ResumesAt = %+v, want resumed", got)
+	}
+
+	if _, err := billing.CancelSubscription(ctx, sub.ID, nil); err != nil {
+		t.Fatalf("CancelSubscription: %v", err)
+	}
+	if _, err := billing.PauseSubscription(ctx, sub.ID, nil); !tedo.IsConflict(err) {
+		t.Errorf("pausing a canceled subscription error = %v, want conflict", err)
+	}
+}
+
+func TestReactivatePendingCancellation(t *testing.T) {
+	ctx := context.Background()
+	srv := tedotest.NewServer(t)
+	billing := srv.Client().Billing
+	sub := srv.SeedSubscription(tedo.Subscription{
+		CustomerID:       "cus_1",
+		CurrentPeriodEnd: tedo.Time{Time: time.Now().Add(24 * time.Hour)},
+	})
+
+	pending, err := billing.CancelSubscription(ctx, sub.ID, &tedo.CancelSubscriptionParams{AtPeriodEnd: true, Reason: "too expensive"})
+	if err != nil {
+		t.Fatalf("CancelSubscription: %v", err)
+	}
+	if !pending.CancelAtPeriodEnd || pending.Status != string(tedo.SubscriptionStatusActive) {
+		t.Fatalf("pending cancellation = %+v, want active and canceling at period end", pending)
+	}
+
+	reactivated, err := billing.ReactivateSubscription(ctx, sub.ID)
+	if err != nil {
+		t.Fatalf("ReactivateSubscription: %v", err)
+	}
+	if reactivated.CancelAtPeriodEnd || reactivated.CancellationReason != "" || reactivated.Status != string(tedo.SubscriptionStatusActive) {
+		t.Errorf("reactivated subscription = %+v, want the pending cancellation undone", reactivated)
+	}
+
+	if _, err := billing.ReactivateSubscription(ctx, "sub_missing"); !tedo.IsNotFound(err) {
+		t.Errorf("ReactivateSubscription of a missing subscription error = %v, want not found", err)
+	}
+}
+
+func TestGetPriceLookups(t *testing.T) {
+	ctx := context.Background()
+	srv := tedotest.NewServer(t)
+	billing := srv.Client().Billing
+	pro := srv.SeedPlan(tedo.Plan{Key: "pro", Name: "Pro", Prices: []tedo.Price{{Key: "monthly", Amount: 999, Interval: "month"}}})
+	team := srv.SeedPlan(tedo.Plan{Key: "team", Name: "Team"})
+	price := pro.Prices[0]
+
+	nested, err := billing.GetPrice(ctx, pro.ID, price.ID)
+	if err != nil || nested.ID != price.ID || nested.Amount != 999 || nested.Interval != "month" {
+		t.Errorf("GetPrice = %+v, %v; want %s", nested, err, price.ID)
+	}
+	flat, err := billing.GetPriceByID(ctx, price.ID)
+	if err != nil || flat.ID != price.ID || flat.PlanID != pro.ID {
+		t.Errorf("GetPriceByID = %+v, %v; want %s of %s", flat, err, price.ID, pro.ID)
+	}
+
+	if _, err := billing.GetPrice(ctx, team.ID, price.ID); !tedo.IsNotFound(err) {
+		t.Errorf("GetPrice under another plan error = %v, want not found", err)
+	}
+	if _, err := billing.GetPrice(ctx, pro.ID, "price_missing"); !tedo.IsNotFound(err) {
+		t.Errorf("GetPrice of a missing price error = %v, want not found", err)
+	}
+	if _, err := billing.GetPriceByID(ctx, "price_missing"); !tedo.IsNotFound(err) {
+		t.Errorf("GetPriceByID of a missing price error = %v, want not found", err)
+	}
+}
+
+func TestEffectiveAt(t *testing.T) {
+	ctx := context.Background()
+	srv := tedotest.NewServer(t)
+	billing := srv.Client().Billing
+	plan := srv.SeedPlan(tedo.Plan{Key: "pro", Name: "Pro", Prices: []tedo.Price{{Key: "monthly", Amount: 3000}, {Key: "team", Amount: 6000}}})
+	periodEnd := time.Now().Add(10 * 24 * time.Hour).UTC().Truncate(time.Second)
+	sub := srv.SeedSubscription(tedo.Subscription{CustomerID: "cus_1", PriceID: plan.Prices[0].ID, CurrentPeriodEnd: tedo.Time{Time: periodEnd}})
+
+	backdated := periodEnd.AddDate(0, 0, -20)
+	preview, err := billing.PreviewSubscriptionChange(ctx, sub.ID, &tedo.PreviewSubscriptionChangeParams{PriceID: plan.Prices[1].ID, EffectiveAt: &backdated})
+	if err != nil {
+		t.Fatalf("PreviewSubscriptionChange: %v", err)
+	}
+	if !preview.ProrationDate.Equal(backdated) {
+		t.Errorf("ProrationDate = %v, want %v", preview.ProrationDate, backdated)
+	}
+	current, err := billing.PreviewSubscriptionChange(ctx, sub.ID, &tedo.PreviewSubscriptionChangeParams{PriceID: plan.Prices[1].ID})
+	if err != nil {
+		t.Fatalf("PreviewSubscriptionChange: %v", err)
+	}
+	if preview.AmountDueNow <= current.AmountDueNow {
+		t.Errorf("backdated amount due %d, want more than %d as of now", preview.AmountDueNow, current.AmountDueNow)
+	}
+
+	if _, err := billing.UpdateSubscription(ctx, sub.ID, &tedo.UpdateSubscriptionParams{PriceID: &plan.Prices[1].ID, EffectiveAt: &backdated}); err != nil {
+		t.Errorf("UpdateSubscription within the period: %v", err)
+	}
+	beforePeriod := periodEnd.AddDate(0, -2, 0)
+	if _, err := billing.UpdateSubscription(ctx, sub.ID, &tedo.UpdateSubscriptionParams{PriceID: &plan.Prices[0].ID, EffectiveAt: &beforePeriod}); !tedo.IsValidationError(err) {
+		t.Errorf("UpdateSubscription before the period error = %v, want a validation error", err)
+	}
+}
+
+func TestChangeSubscriptionPriceChangeSummary(t *testing.T) {
+	ctx := context.Background()
+	srv := tedotest.NewServer(t)
+	basic := srv.SeedPlan(tedo.Plan{Key: "basic", Name: "Basic", Prices: []tedo.Price{{Key: "monthly", Amount: 900}}})
+	srv.SeedPlan(tedo.Plan{Key: "pro", Name: "Pro", Prices: []tedo.Price{{Key: "monthly", Amount: 2900}}})
+	sub := srv.SeedSubscription(tedo.Subscription{CustomerID: "cus_1", PriceID: basic.Prices[0].ID, Quantity: 2})
+
+	pro := "pro"
+	result, err := srv.Client().Billing.ChangeSubscription(ctx, sub.ID, &tedo.ChangeSubscriptionParams{
+		UpdateSubscriptionParams: tedo.UpdateSubscriptionParams{PlanKey: &pro},
+		ExpandPrices:             true,
+	})
+	if err != nil {
+		t.Fatalf("ChangeSubscription: %v", err)
+	}
+	oldAmount, newAmount, err := result.PriceChangeSummary()
+	if err != nil {
+		t.Fatalf("PriceChangeSummary: %v", err)
+	}
+	if oldAmount.Amount != 1800 || newAmount.Amount != 5800 || newAmount.Currency != "eur" {
+		t.Errorf("PriceChangeSummary = %+v, %+v; want 1800 and 5800 eur", oldAmount, newAmount)
+	}
+	if result.Subscription.PlanKey != "pro" {
+		t.Errorf("subscription plan = %q, want pro", result.Subscription.PlanKey)
+	}
+}
@@ -0,0 +1,64 @@
+package tedo
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// Time is a timestamp in an API response. Besides RFC 3339 strings, with or
+// without fractional seconds, it decodes the Unix epoch integers (seconds
+// or milliseconds) some older records carry, and null as the zero time. It
+// always encodes as an RFC 3339 string.
+type Time struct {
+	time.Time
+}
+
+// epochMillisThreshold separates epoch seconds from epoch milliseconds:
+// larger values are read as milliseconds. In seconds it lies in the year
+// 5138, in milliseconds in 1973.
+const epochMillisThreshold = 1e11
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (t *Time) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	if string(data) == "null" {
+		*t = Time{}
+		return nil
+	}
+
+	if len(data) > 0 && data[0] == '"' {
+		var s string
+		if err := json.Unmarshal(data, &s); err != nil {
+			return err
+		}
+		if s == "" {
+			*t = Time{}
+			return nil
+		}
+		parsed, err := time.Parse(time.RFC3339Nano, s)
+		if err != nil {
+			return fmt.Errorf("tedo: invalid timestamp %q: %w", s, err)
+		}
+		*t = Time{parsed}
+		return nil
+	}
+
+	epoch, err := strconv.ParseInt(string(data), 10, 64)
+	if err != nil {
+		return fmt.Errorf("tedo: invalid timestamp %s", data)
+	}
+	if epoch > epochMillisThreshold || epoch < -epochMillisThreshold {
+		*t = Time{time.UnixMilli(epoch).UTC()}
+	} else {
+		*t = Time{time.Unix(epoch, 0).UTC()}
+	}
+	return nil
+}
+
+// MarshalJSON implements json.Marshaler.
+func (t Time) MarshalJSON() ([]byte, error) {
+	return t.Time.MarshalJSON()
+}
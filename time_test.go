@@ -0,0 +1,70 @@
+package tedo
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestTimeUnmarshalJSON(t *testing.T) {
+	want := time.Date(2026, 3, 14, 15, 9, 26, 0, time.UTC)
+	tests := []struct {
+		name    string
+		json    string
+		want    time.Time
+		wantErr bool
+	}{
+		{"RFC 3339", `"2026-03-14T15:09:26Z"`, want, false},
+		{"fractional seconds", `"2026-03-14T15:09:26.535Z"`, want.Add(535 * time.Millisecond), false},
+		{"offset", `"2026-03-14T16:09:26+01:00"`, want, false},
+		{"epoch seconds", `1773500966`, want, false},
+		{"epoch milliseconds", `1773500966535`, want.Add(535 * time.Millisecond), false},
+		{"negative epoch seconds", `-86400`, time.Unix(-86400, 0).UTC(), false},
+		{"null", `null`, time.Time{}, false},
+		{"empty string", `""`, time.Time{}, false},
+		{"date only", `"2026-03-14"`, time.Time{}, true},
+		{"garbage string", `"yesterday"`, time.Time{}, true},
+		{"float", `1773500966.5`, time.Time{}, true},
+		{"bool", `true`, time.Time{}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got Time
+			err := json.Unmarshal([]byte(tt.json), &got)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("Unmarshal(%s) = %v, want an error", tt.json, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unmarshal(%s): %v", tt.json, err)
+			}
+			if !got.Equal(tt.want) {
+				t.Errorf("Unmarshal(%s) = %v, want %v", tt.json, got.Time, tt.want)
+			}
+		})
+	}
+}
+
+func TestTimeInStructs(t *testing.T) {
+	var sub Subscription
+	data := `{"started_at":1773500966,"current_period_end":"2026-04-14T15:09:26Z","canceled_at":null,"paused_at":"2026-03-20T00:00:00.5+00:00"}`
+	if err := json.Unmarshal([]byte(data), &sub); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	if sub.StartedAt.Unix() != 1773500966 || sub.CurrentPeriodEnd.Month() != time.April {
+		t.Errorf("decoded %v and %v", sub.StartedAt, sub.CurrentPeriodEnd)
+	}
+	if sub.CanceledAt != nil {
+		t.Errorf("CanceledAt = %v, want nil for null", sub.CanceledAt)
+	}
+	if sub.PausedAt == nil || sub.PausedAt.Nanosecond() != 5e8 {
+		t.Errorf("PausedAt = %v", sub.PausedAt)
+	}
+
+	out, err := json.Marshal(sub.StartedAt)
+	if err != nil || string(out) != `"2026-03-14T15:09:26Z"` {
+		t.Errorf("Marshal = %s, %v; want an RFC 3339 string", out, err)
+	}
+}